@@ -0,0 +1,82 @@
+package serifu
+
+import (
+	"sort"
+	"strings"
+)
+
+const (
+	attrsStart     = "{"
+	attrsEnd       = "}"
+	attrsSeparator = ","
+	attrsAssign    = "="
+)
+
+// Attrs are the attributes of a node written at the end of its line:
+//
+//	# PAGE 3 {status=lettered, reviewer=AB}
+//
+// Keys and values can not contain commas, braces or the bar separating ruby
+// text from its annotation, so {E=mc|formula} stays ruby.
+type Attrs = map[string]string
+
+// cutAttrs splits the attribute list from the end of s. A trailing braced
+// group is an attribute list only when every comma separated part of it is
+// a key=value pair, otherwise s is returned unchanged.
+func cutAttrs(s string) (string, Attrs) {
+	s = strings.TrimSpace(s)
+	if !strings.HasSuffix(s, attrsEnd) {
+		return s, nil
+	}
+	i := strings.LastIndex(s, attrsStart)
+	if i < 0 {
+		return s, nil
+	}
+	inner := strings.TrimSpace(s[i+len(attrsStart) : len(s)-len(attrsEnd)])
+	if inner == "" || strings.ContainsRune(inner, rubySeparator) {
+		return s, nil
+	}
+	attrs := make(Attrs)
+	for _, pair := range strings.Split(inner, attrsSeparator) {
+		j := strings.Index(pair, attrsAssign)
+		if j < 0 {
+			return s, nil
+		}
+		key := strings.TrimSpace(pair[:j])
+		if key == "" {
+			return s, nil
+		}
+		attrs[key] = strings.TrimSpace(pair[j+len(attrsAssign):])
+	}
+	return strings.TrimSpace(s[:i]), attrs
+}
+
+// formatAttrs returns the attribute list of attrs sorted by key or an empty
+// string when there are none
+func formatAttrs(attrs Attrs) string {
+	if len(attrs) == 0 {
+		return ""
+	}
+	keys := make([]string, 0, len(attrs))
+	for k := range attrs {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	pairs := make([]string, len(keys))
+	for i, k := range keys {
+		pairs[i] = k + attrsAssign + attrs[k]
+	}
+	return attrsStart + strings.Join(pairs, attrsSeparator+" ") + attrsEnd
+}
+
+// withAttrs appends the attribute list of attrs to the line s
+func withAttrs(s string, attrs Attrs) string {
+	a := formatAttrs(attrs)
+	if a == "" {
+		return s
+	}
+	if s == "" {
+		return a
+	}
+	return s + " " + a
+}
@@ -0,0 +1,100 @@
+package serifu
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCutAttrs(t *testing.T) {
+	tests := []struct {
+		in        string
+		wantText  string
+		wantAttrs Attrs
+	}{
+		{"1.2", "1.2", nil},
+		{"1.2 {status=lettered}", "1.2", Attrs{"status": "lettered"}},
+		{"1.2{ x = 10 , y=20, note=}", "1.2", Attrs{"x": "10", "y": "20", "note": ""}},
+		{"{font size=12pt}", "", Attrs{"font size": "12pt"}},
+		{"a {set} of braces", "a {set} of braces", nil},
+		{"a {set}", "a {set}", nil},
+		{"a {x=1, y}", "a {x=1, y}", nil},
+		{"a {=1}", "a {=1}", nil},
+		{"a {}", "a {}", nil},
+		{"a {x=1} {y=2}", "a {x=1}", Attrs{"y": "2"}},
+		{"see {E|m=c²}", "see {E|m=c²}", nil},
+		{"{a|b} {x=1}", "{a|b}", Attrs{"x": "1"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			text, attrs := cutAttrs(tt.in)
+			if text != tt.wantText || !reflect.DeepEqual(attrs, tt.wantAttrs) {
+				t.Errorf("cutAttrs() = %q, %v, want %q, %v", text, attrs, tt.wantText, tt.wantAttrs)
+			}
+		})
+	}
+}
+
+func TestParse_attrs(t *testing.T) {
+	input := `# PAGE 1 {status=lettered}
+- 1.1 {x=10, y=20}
+* BAM (ban) [top] {font=Impact}
+Shota: Hi! {position=top left}
+Sign:/= {size=small}
+Menu
+=/
+`
+	s, err := Parse(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	page := s.Pages[0]
+	panel := page.Panels[0]
+	sound := panel.Items[0].(*SoundEffect)
+	line := panel.Items[1].(*TextLine)
+	sign := panel.Items[2].(*TextLine)
+	got := []Attrs{page.Attrs, panel.Attrs, sound.Attrs, line.Attrs, sign.Attrs}
+	want := []Attrs{
+		{"status": "lettered"},
+		{"x": "10", "y": "20"},
+		{"font": "Impact"},
+		{"position": "top left"},
+		{"size": "small"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("attrs = %v, want %v", got, want)
+	}
+	if page.Title != "PAGE 1" || panel.ID != "1.1" || sound.Placement != "top" || line.Content != "Hi!" || sign.Content != "Menu\n" {
+		t.Errorf("Parse() = %v, want attributes cut from the nodes", s)
+	}
+
+	if got := s.String(); got != input {
+		t.Errorf("Script.String() = %q, want %q", got, input)
+	}
+}
+
+func TestParse_attrsAfterRuby(t *testing.T) {
+	tests := []struct {
+		content string
+		want    string
+	}{
+		{"see {E|m=c²}", "see ruby(E|m=c²)"},
+		{"{E=mc|formula}", "ruby(E=mc|formula)"},
+		{"see {a=b|c}", "see ruby(a=b|c)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.content, func(t *testing.T) {
+			s, err := Parse(strings.NewReader("# PAGE 1\n- 1\nAki: " + tt.content + "\n"))
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			line := s.Pages[0].Panels[0].Items[0].(*TextLine)
+			if line.Content != tt.content || line.Attrs != nil {
+				t.Errorf("Parse() = %q, %v, want the ruby kept in the content", line.Content, line.Attrs)
+			}
+			if got := spanString(ParseInline(line.Content)); got != tt.want {
+				t.Errorf("ParseInline() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/aquilax/serifu-go"
+	"github.com/aquilax/serifu-go/lint"
+)
+
+var checkCommand = &command{
+	name:  "check",
+	short: "report every problem in scripts",
+}
+
+func init() {
+	checkCommand.run = runCheck
+	commands = append(commands, checkCommand)
+}
+
+// runCheck parses the inputs in recovering mode and reports all problems.
+// It fails if any input has errors.
+func runCheck(e *env, args []string) int {
+	fs := newFlagSet(e, checkCommand, "[-lint] [-config file] [files]")
+	useLint := fs.Bool("lint", false, "run the lint rules too")
+	config := fs.String("config", "", "lint configuration file (implies -lint)")
+	if err := fs.Parse(args); err != nil {
+		return exitUsage
+	}
+	var linter *lint.Linter
+	if *useLint || *config != "" {
+		var cfg *lint.Config
+		if *config != "" {
+			var err error
+			if cfg, err = lint.LoadConfig(*config); err != nil {
+				report(e.stderr, "serifu", err)
+				return exitUsage
+			}
+		}
+		var err error
+		if linter, err = lint.New(lint.DefaultRules(), cfg); err != nil {
+			report(e.stderr, *config, err)
+			return exitUsage
+		}
+	}
+	inputs, err := readInputs(e, fs.Args())
+	if err != nil {
+		report(e.stderr, "serifu", err)
+		return exitError
+	}
+	code := exitOK
+	for _, in := range inputs {
+		script, err := serifu.ParseWithOptions(bytes.NewReader(in.data), serifu.ParserOptions{Recover: true})
+		if err != nil {
+			report(e.stderr, in.name, err)
+			code = exitError
+		}
+		if linter == nil || script == nil {
+			continue
+		}
+		for _, p := range linter.Lint(script) {
+			fmt.Fprintf(e.stderr, "%s:%d:%d: %s: %s (%s)\n", in.name, p.Pos.Line, p.Pos.Column, p.Severity, p.Msg, p.Rule)
+			if p.Severity == serifu.SeverityError {
+				code = exitError
+			}
+		}
+	}
+	return code
+}
@@ -0,0 +1,183 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/aquilax/serifu-go"
+)
+
+// format is a script representation convert can read or write
+type format struct {
+	name  string
+	exts  []string
+	read  func(r io.Reader) (*serifu.Script, error)
+	write func(w io.Writer, s *serifu.Script) error
+}
+
+var formats = map[string]*format{
+	"serifu": {
+		name: "serifu",
+		exts: []string{".serifu", ".txt"},
+		read: serifu.Parse,
+		write: func(w io.Writer, s *serifu.Script) error {
+			return serifu.Format(w, s, serifu.FormatOptions{})
+		},
+	},
+	"json": {
+		name:  "json",
+		exts:  []string{".json"},
+		read:  serifu.DecodeJSON,
+		write: serifu.EncodeJSON,
+	},
+	"html": {
+		name: "html",
+		exts: []string{".html", ".htm"},
+		write: func(w io.Writer, s *serifu.Script) error {
+			return serifu.RenderHTML(w, s, serifu.HTMLOptions{EmbedStylesheet: true})
+		},
+	},
+	"csv": {
+		name: "csv",
+		exts: []string{".csv"},
+		read: func(r io.Reader) (*serifu.Script, error) {
+			return serifu.DecodeCSV(r, serifu.CSVOptions{})
+		},
+		write: func(w io.Writer, s *serifu.Script) error {
+			return serifu.EncodeCSV(w, s, serifu.CSVOptions{})
+		},
+	},
+	"tsv": {
+		name: "tsv",
+		exts: []string{".tsv"},
+		read: func(r io.Reader) (*serifu.Script, error) {
+			return serifu.DecodeCSV(r, serifu.CSVOptions{Comma: '\t'})
+		},
+		write: func(w io.Writer, s *serifu.Script) error {
+			return serifu.EncodeCSV(w, s, serifu.CSVOptions{Comma: '\t'})
+		},
+	},
+	"docx": {
+		name: "docx",
+		exts: []string{".docx"},
+		read: func(r io.Reader) (*serifu.Script, error) {
+			return serifu.DecodeDOCX(r, serifu.DOCXImportOptions{})
+		},
+		write: func(w io.Writer, s *serifu.Script) error {
+			return serifu.EncodeDOCX(w, s, serifu.DOCXOptions{})
+		},
+	},
+	"markdown": {
+		name: "markdown",
+		exts: []string{".md", ".markdown"},
+		write: func(w io.Writer, s *serifu.Script) error {
+			return serifu.RenderMarkdown(w, s, serifu.MarkdownOptions{})
+		},
+	},
+}
+
+// formatNames returns the names of the formats supporting the operation
+func formatNames(readable bool) string {
+	var names []string
+	for name, f := range formats {
+		if (readable && f.read != nil) || (!readable && f.write != nil) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
+
+// formatFor returns the named format or guesses it from the file name
+func formatFor(name, file string) (*format, error) {
+	if name != "" {
+		if f, ok := formats[name]; ok {
+			return f, nil
+		}
+		return nil, fmt.Errorf("unknown format %q", name)
+	}
+	ext := strings.ToLower(filepath.Ext(file))
+	for _, f := range formats {
+		for _, e := range f.exts {
+			if e == ext {
+				return f, nil
+			}
+		}
+	}
+	return formats["serifu"], nil
+}
+
+var convertCommand = &command{
+	name:  "convert",
+	short: "convert a script between formats",
+}
+
+func init() {
+	convertCommand.run = runConvert
+	commands = append(commands, convertCommand)
+}
+
+func runConvert(e *env, args []string) int {
+	fs := newFlagSet(e, convertCommand, "[-from format] -to format [-o file] [file]")
+	from := fs.String("from", "", "input format: "+formatNames(true)+" (default from the file extension)")
+	to := fs.String("to", "", "output format: "+formatNames(false)+" (default from the output file extension)")
+	out := fs.String("o", "", "output file (default standard output)")
+	if err := fs.Parse(args); err != nil {
+		return exitUsage
+	}
+	if fs.NArg() > 1 || (*to == "" && *out == "") {
+		fs.Usage()
+		return exitUsage
+	}
+	inputs, err := readInputs(e, fs.Args())
+	if err != nil {
+		report(e.stderr, "serifu", err)
+		return exitError
+	}
+	in := inputs[0]
+	rf, err := formatFor(*from, in.name)
+	if err == nil && rf.read == nil {
+		err = fmt.Errorf("format %q can't be read", rf.name)
+	}
+	if err != nil {
+		report(e.stderr, "serifu", err)
+		return exitUsage
+	}
+	wf, err := formatFor(*to, *out)
+	if err == nil && wf.write == nil {
+		err = fmt.Errorf("format %q can't be written", wf.name)
+	}
+	if err != nil {
+		report(e.stderr, "serifu", err)
+		return exitUsage
+	}
+	code := exitOK
+	script, err := rf.read(bytes.NewReader(in.data))
+	if err != nil {
+		// readers returning a partial script skipped some of the input
+		report(e.stderr, in.name, err)
+		if script == nil {
+			return exitError
+		}
+		code = exitError
+	}
+	var b bytes.Buffer
+	if err := wf.write(&b, script); err != nil {
+		report(e.stderr, in.name, err)
+		return exitError
+	}
+	if *out == "" {
+		e.stdout.Write(b.Bytes())
+		return code
+	}
+	if err := os.WriteFile(*out, b.Bytes(), 0644); err != nil {
+		report(e.stderr, *out, err)
+		return exitError
+	}
+	return code
+}
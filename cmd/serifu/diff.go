@@ -0,0 +1,184 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// diffContext is the number of unchanged lines shown around a change
+const diffContext = 3
+
+// diffOp is a single line of an edit script
+type diffOp struct {
+	kind byte // ' ', '-' or '+'
+	text string
+}
+
+// unifiedDiff returns the difference between a and b in unified format
+func unifiedDiff(name string, a, b []byte) []byte {
+	ops := diffLines(splitLines(a), splitLines(b))
+	var out bytes.Buffer
+	fmt.Fprintf(&out, "--- %s.orig\n+++ %s\n", name, name)
+	for i := 0; i < len(ops); {
+		if ops[i].kind == ' ' {
+			i++
+			continue
+		}
+		// find the extent of the hunk including the context
+		start := i - diffContext
+		if start < 0 {
+			start = 0
+		}
+		end := i
+		for end < len(ops) {
+			if ops[end].kind != ' ' {
+				end++
+				continue
+			}
+			run := end
+			for run < len(ops) && ops[run].kind == ' ' {
+				run++
+			}
+			if run == len(ops) || run-end > 2*diffContext {
+				end += diffContext
+				if end > len(ops) {
+					end = len(ops)
+				}
+				break
+			}
+			end = run
+		}
+		aStart, bStart := 1, 1
+		for _, op := range ops[:start] {
+			if op.kind != '+' {
+				aStart++
+			}
+			if op.kind != '-' {
+				bStart++
+			}
+		}
+		aLen, bLen := 0, 0
+		for _, op := range ops[start:end] {
+			if op.kind != '+' {
+				aLen++
+			}
+			if op.kind != '-' {
+				bLen++
+			}
+		}
+		fmt.Fprintf(&out, "@@ -%d,%d +%d,%d @@\n", aStart, aLen, bStart, bLen)
+		for _, op := range ops[start:end] {
+			out.WriteByte(op.kind)
+			out.WriteString(op.text)
+			out.WriteByte('\n')
+		}
+		i = end
+	}
+	return out.Bytes()
+}
+
+func splitLines(data []byte) []string {
+	s := strings.TrimSuffix(string(data), "\n")
+	if s == "" {
+		return nil
+	}
+	return strings.Split(s, "\n")
+}
+
+// diffLines returns the edit script turning a into b. It uses the linear
+// space variant of Myers' O(ND) algorithm so large scripts with few changes
+// are compared quickly.
+func diffLines(a, b []string) []diffOp {
+	var ops []diffOp
+	diffRange(&ops, a, b)
+	return ops
+}
+
+// diffRange appends the edit script turning a into b to ops
+func diffRange(ops *[]diffOp, a, b []string) {
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		*ops = append(*ops, diffOp{' ', a[prefix]})
+		prefix++
+	}
+	a, b = a[prefix:], b[prefix:]
+	suffix := 0
+	for suffix < len(a) && suffix < len(b) && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+	common := a[len(a)-suffix:]
+	a, b = a[:len(a)-suffix], b[:len(b)-suffix]
+	switch {
+	case len(a) == 0:
+		for _, l := range b {
+			*ops = append(*ops, diffOp{'+', l})
+		}
+	case len(b) == 0:
+		for _, l := range a {
+			*ops = append(*ops, diffOp{'-', l})
+		}
+	default:
+		x, y, u, v := middleSnake(a, b)
+		diffRange(ops, a[:x], b[:y])
+		for _, l := range a[x:u] {
+			*ops = append(*ops, diffOp{' ', l})
+		}
+		diffRange(ops, a[u:], b[v:])
+	}
+	for _, l := range common {
+		*ops = append(*ops, diffOp{' ', l})
+	}
+}
+
+// middleSnake returns the start x, y and the end u, v of the middle snake
+// of an optimal path from the start to the end of a and b, searching from
+// both ends at once
+func middleSnake(a, b []string) (x, y, u, v int) {
+	n, m := len(a), len(b)
+	delta := n - m
+	odd := delta%2 != 0
+	max := (n + m + 1) / 2
+	offset := max + 1
+	vf := make([]int, 2*max+3)
+	vb := make([]int, 2*max+3)
+	for d := 0; d <= max; d++ {
+		// forward paths, x and y count from the start
+		for k := -d; k <= d; k += 2 {
+			if k == -d || (k != d && vf[offset+k-1] < vf[offset+k+1]) {
+				x = vf[offset+k+1]
+			} else {
+				x = vf[offset+k-1] + 1
+			}
+			y = x - k
+			u, v = x, y
+			for u < n && v < m && a[u] == b[v] {
+				u++
+				v++
+			}
+			vf[offset+k] = u
+			if kb := delta - k; odd && kb >= -(d-1) && kb <= d-1 && u+vb[offset+kb] >= n {
+				return x, y, u, v
+			}
+		}
+		// backward paths, x and y count from the end
+		for k := -d; k <= d; k += 2 {
+			if k == -d || (k != d && vb[offset+k-1] < vb[offset+k+1]) {
+				x = vb[offset+k+1]
+			} else {
+				x = vb[offset+k-1] + 1
+			}
+			y = x - k
+			u, v = x, y
+			for u < n && v < m && a[n-1-u] == b[m-1-v] {
+				u++
+				v++
+			}
+			vb[offset+k] = u
+			if kf := delta - k; !odd && kf >= -d && kf <= d && u+vf[offset+kf] >= n {
+				return n - u, m - v, n - x, m - y
+			}
+		}
+	}
+	panic("diff: paths did not meet")
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"math/rand"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// lcsLength returns the length of the longest common subsequence of a and b
+func lcsLength(a, b []string) int {
+	prev := make([]int, len(b)+1)
+	for i := range a {
+		cur := make([]int, len(b)+1)
+		for j := range b {
+			switch {
+			case a[i] == b[j]:
+				cur[j+1] = prev[j] + 1
+			case prev[j+1] >= cur[j]:
+				cur[j+1] = prev[j+1]
+			default:
+				cur[j+1] = cur[j]
+			}
+		}
+		prev = cur
+	}
+	return prev[len(b)]
+}
+
+// checkDiff checks that ops turn a into b with the fewest changes
+func checkDiff(t *testing.T, a, b []string, ops []diffOp) {
+	t.Helper()
+	var gotA, gotB []string
+	changes := 0
+	for _, op := range ops {
+		if op.kind != '+' {
+			gotA = append(gotA, op.text)
+		}
+		if op.kind != '-' {
+			gotB = append(gotB, op.text)
+		}
+		if op.kind != ' ' {
+			changes++
+		}
+	}
+	if strings.Join(gotA, "\n") != strings.Join(a, "\n") || strings.Join(gotB, "\n") != strings.Join(b, "\n") {
+		t.Fatalf("diffLines(%q, %q) = %v, does not turn a into b", a, b, ops)
+	}
+	if want := len(a) + len(b) - 2*lcsLength(a, b); changes != want {
+		t.Errorf("diffLines(%q, %q) has %d changes, want %d", a, b, changes, want)
+	}
+}
+
+func TestDiffLines(t *testing.T) {
+	tests := []struct {
+		a, b string
+	}{
+		{"", ""},
+		{"a", ""},
+		{"", "a b"},
+		{"a b c", "a b c"},
+		{"a b c a b b a", "c b a b a c"},
+		{"x a y", "a"},
+		{"a b c d e", "a x c y e"},
+	}
+	for _, tt := range tests {
+		a, b := strings.Fields(tt.a), strings.Fields(tt.b)
+		checkDiff(t, a, b, diffLines(a, b))
+	}
+	rnd := rand.New(rand.NewSource(1))
+	for n := 0; n < 200; n++ {
+		a := make([]string, rnd.Intn(20))
+		for i := range a {
+			a[i] = strconv.Itoa(rnd.Intn(4))
+		}
+		b := make([]string, rnd.Intn(20))
+		for i := range b {
+			b[i] = strconv.Itoa(rnd.Intn(4))
+		}
+		checkDiff(t, a, b, diffLines(a, b))
+	}
+}
+
+func TestDiffLines_large(t *testing.T) {
+	a := make([]string, 10000)
+	for i := range a {
+		a[i] = "line " + strconv.Itoa(i)
+	}
+	b := append([]string(nil), a...)
+	b[10] = "changed"
+	b = append(b[:5000], b[5001:]...)
+	ops := diffLines(a, b)
+	if len(ops) != 10001 {
+		t.Errorf("diffLines() = %d ops, want 10001", len(ops))
+	}
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"bytes"
+	"os"
+
+	"github.com/aquilax/serifu-go"
+)
+
+var fmtCommand = &command{
+	name:  "fmt",
+	short: "format scripts in place",
+}
+
+func init() {
+	fmtCommand.run = runFmt
+	commands = append(commands, fmtCommand)
+}
+
+// runFmt formats the files in place like gofmt -w. Standard input is
+// formatted to standard output.
+func runFmt(e *env, args []string) int {
+	fs := newFlagSet(e, fmtCommand, "[-l] [-d] [files]")
+	list := fs.Bool("l", false, "list files whose formatting differs")
+	diff := fs.Bool("d", false, "display diffs instead of rewriting files")
+	indent := fs.String("indent", "", "indentation of panels and items")
+	blank := fs.Int("blank", 0, "number of blank lines between panels")
+	noSpace := fs.Bool("nospace", false, "no space after the text line colon")
+	if err := fs.Parse(args); err != nil {
+		return exitUsage
+	}
+	opts := serifu.FormatOptions{
+		Indent:                  *indent,
+		BlankLinesBetweenPanels: *blank,
+		NoSpaceAfterColon:       *noSpace,
+	}
+	inputs, err := readInputs(e, fs.Args())
+	if err != nil {
+		report(e.stderr, "serifu", err)
+		return exitError
+	}
+	code := exitOK
+	for _, in := range inputs {
+		doc, err := serifu.ParseDocument(bytes.NewReader(in.data), serifu.ParserOptions{})
+		if err != nil {
+			report(e.stderr, in.name, err)
+			code = exitError
+			continue
+		}
+		var b bytes.Buffer
+		if err := serifu.FormatDocument(&b, doc, opts); err != nil {
+			report(e.stderr, in.name, err)
+			code = exitError
+			continue
+		}
+		formatted := b.Bytes()
+		changed := !bytes.Equal(in.data, formatted)
+		if *list && changed {
+			e.stdout.Write([]byte(in.name + "\n"))
+		}
+		if *diff && changed {
+			e.stdout.Write(unifiedDiff(in.name, in.data, formatted))
+		}
+		if *list || *diff {
+			continue
+		}
+		if len(fs.Args()) == 0 {
+			e.stdout.Write(formatted)
+			continue
+		}
+		if changed {
+			if err := writeFile(in.name, formatted); err != nil {
+				report(e.stderr, in.name, err)
+				code = exitError
+			}
+		}
+	}
+	return code
+}
+
+// writeFile replaces the content of the file keeping its permissions
+func writeFile(name string, data []byte) error {
+	fi, err := os.Stat(name)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(name, data, fi.Mode().Perm())
+}
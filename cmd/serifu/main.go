@@ -0,0 +1,140 @@
+// Command serifu works with scripts written in the Serifu markup language.
+//
+// Usage:
+//
+//	serifu <command> [flags] [files]
+//
+// Files are read from standard input when none are given.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/aquilax/serifu-go"
+)
+
+const (
+	exitOK    = 0
+	exitError = 1
+	exitUsage = 2
+)
+
+// command is a serifu subcommand
+type command struct {
+	name  string
+	short string
+	run   func(env *env, args []string) int
+}
+
+// env holds the streams used by a command
+type env struct {
+	stdin  io.Reader
+	stdout io.Writer
+	stderr io.Writer
+}
+
+var commands []*command
+
+func main() {
+	os.Exit(run(&env{os.Stdin, os.Stdout, os.Stderr}, os.Args[1:]))
+}
+
+func run(e *env, args []string) int {
+	if len(args) == 0 {
+		usage(e.stderr)
+		return exitUsage
+	}
+	for _, c := range commands {
+		if c.name == args[0] {
+			return c.run(e, args[1:])
+		}
+	}
+	if args[0] == "help" || args[0] == "-h" || args[0] == "--help" {
+		usage(e.stdout)
+		return exitOK
+	}
+	fmt.Fprintf(e.stderr, "serifu: unknown command %q\n", args[0])
+	usage(e.stderr)
+	return exitUsage
+}
+
+func usage(w io.Writer) {
+	fmt.Fprintln(w, "usage: serifu <command> [flags] [files]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Commands:")
+	for _, c := range commands {
+		fmt.Fprintf(w, "  %-8s %s\n", c.name, c.short)
+	}
+}
+
+// newFlagSet returns a flag set for the command writing errors to e.stderr
+func newFlagSet(e *env, c *command, args string) *flag.FlagSet {
+	fs := flag.NewFlagSet(c.name, flag.ContinueOnError)
+	fs.SetOutput(e.stderr)
+	fs.Usage = func() {
+		fmt.Fprintf(e.stderr, "usage: serifu %s %s\n", c.name, args)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// input is a named script source
+type input struct {
+	name string
+	data []byte
+}
+
+// readInputs reads the named files or standard input when there are none
+func readInputs(e *env, names []string) ([]input, error) {
+	if len(names) == 0 {
+		data, err := io.ReadAll(e.stdin)
+		if err != nil {
+			return nil, err
+		}
+		return []input{{"<standard input>", data}}, nil
+	}
+	inputs := make([]input, 0, len(names))
+	for _, name := range names {
+		data, err := os.ReadFile(name)
+		if err != nil {
+			return nil, err
+		}
+		inputs = append(inputs, input{name, data})
+	}
+	return inputs, nil
+}
+
+// report writes err to w prefixing parse errors with their file position
+func report(w io.Writer, name string, err error) {
+	var list serifu.ErrorList
+	if errors.As(err, &list) {
+		for _, pe := range list {
+			report(w, name, pe)
+		}
+		return
+	}
+	var rows serifu.DOCXRowErrors
+	if errors.As(err, &rows) {
+		for _, re := range rows {
+			report(w, name, re)
+		}
+		return
+	}
+	var csvRows serifu.CSVRowErrors
+	if errors.As(err, &csvRows) {
+		for _, re := range csvRows {
+			fmt.Fprintf(w, "%s:%d: %s\n", name, re.Line, re.Msg)
+		}
+		return
+	}
+	var pe *serifu.ParseError
+	if errors.As(err, &pe) {
+		fmt.Fprintf(w, "%s:%d:%d: %s: %s\n", name, pe.Pos.Line, pe.Pos.Column, pe.Severity, pe.Msg)
+		return
+	}
+	fmt.Fprintf(w, "%s: %v\n", name, err)
+}
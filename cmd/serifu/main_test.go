@@ -0,0 +1,218 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// runCommand runs serifu with args and stdin returning the exit code and
+// the captured output
+func runCommand(args []string, stdin string) (int, string, string) {
+	var stdout, stderr bytes.Buffer
+	code := run(&env{strings.NewReader(stdin), &stdout, &stderr}, args)
+	return code, stdout.String(), stderr.String()
+}
+
+func TestRun_usage(t *testing.T) {
+	if code, _, _ := runCommand(nil, ""); code != exitUsage {
+		t.Errorf("run() = %d, want %d", code, exitUsage)
+	}
+	if code, _, stderr := runCommand([]string{"nope"}, ""); code != exitUsage || !strings.Contains(stderr, "unknown command") {
+		t.Errorf("run(nope) = %d, %q", code, stderr)
+	}
+}
+
+func TestRun_fmt(t *testing.T) {
+	tests := []struct {
+		name       string
+		args       []string
+		stdin      string
+		wantCode   int
+		wantStdout string
+		wantStderr string
+	}{
+		{
+			"formats standard input",
+			[]string{"fmt"},
+			"#  PAGE 1\n-1.1\nShota :Hi\n",
+			exitOK,
+			"# PAGE 1\n- 1.1\nShota: Hi\n",
+			"",
+		},
+		{
+			"lists standard input",
+			[]string{"fmt", "-l"},
+			"#  PAGE 1\n",
+			exitOK,
+			"<standard input>\n",
+			"",
+		},
+		{
+			"shows diff",
+			[]string{"fmt", "-d"},
+			"# PAGE 1\n-1.1\n",
+			exitOK,
+			"--- <standard input>.orig\n+++ <standard input>\n@@ -1,2 +1,2 @@\n # PAGE 1\n--1.1\n+- 1.1\n",
+			"",
+		},
+		{
+			"reports parse errors",
+			[]string{"fmt"},
+			"# PAGE 1\nbad\n",
+			exitError,
+			"",
+			"<standard input>:2:1: error: unexpected markup: `bad`\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, stdout, stderr := runCommand(tt.args, tt.stdin)
+			if code != tt.wantCode {
+				t.Errorf("run() = %d, want %d", code, tt.wantCode)
+			}
+			if stdout != tt.wantStdout {
+				t.Errorf("stdout = %q, want %q", stdout, tt.wantStdout)
+			}
+			if stderr != tt.wantStderr {
+				t.Errorf("stderr = %q, want %q", stderr, tt.wantStderr)
+			}
+		})
+	}
+}
+
+func TestRun_fmtInPlace(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "chapter.serifu")
+	if err := os.WriteFile(name, []byte("# PAGE 1\n-1.1\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if code, stdout, stderr := runCommand([]string{"fmt", name}, ""); code != exitOK || stdout != "" {
+		t.Fatalf("run() = %d, %q, %q", code, stdout, stderr)
+	}
+	got, err := os.ReadFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "# PAGE 1\n- 1.1\n"; string(got) != want {
+		t.Errorf("file = %q, want %q", got, want)
+	}
+}
+
+const testScript = "## PAGE 1\n- 1.1\nShota/Sharp: A _death match?!?_\n* gasp (haa)\nShota: Hi\n! note\n"
+
+func TestRun_commands(t *testing.T) {
+	tests := []struct {
+		name       string
+		args       []string
+		stdin      string
+		wantCode   int
+		wantStdout string
+		wantStderr string
+	}{
+		{
+			"parse bare",
+			[]string{"parse", "-bare"},
+			"# P\n- 1\n! n\n",
+			exitOK,
+			"{\n  \"pages\": [\n    {\n      \"title\": \"P\",\n      \"is_spread\": false,\n      \"panels\": [\n        {\n          \"id\": \"1\",\n          \"items\": [\n            {\n              \"type\": \"sideNote\",\n              \"content\": \"n\"\n            }\n          ]\n        }\n      ]\n    }\n  ]\n}\n",
+			"",
+		},
+		{
+			"check reports every error",
+			[]string{"check"},
+			"- 0\n# P\n* s\nbad\n",
+			exitError,
+			"",
+			"<standard input>:1:1: error: unexpected panel definition outside of page\n" +
+				"<standard input>:3:1: error: unexpected sound definition outside of panel\n" +
+				"<standard input>:4:1: error: unexpected markup: `bad`\n",
+		},
+		{
+			"check valid",
+			[]string{"check"},
+			testScript,
+			exitOK,
+			"",
+			"",
+		},
+		{
+			"check with lint",
+			[]string{"check", "-lint"},
+			"# PAGE 1\n- 1.1\nShota:\nShota: Hi\n- 1.1\n",
+			exitError,
+			"",
+			"<standard input>:3:1: warning: text line of \"Shota\" has no content (empty-text-line)\n" +
+				"<standard input>:5:1: error: duplicate panel ID \"1.1\" on page \"PAGE 1\" (duplicate-panel-id)\n",
+		},
+		{
+			"convert json to serifu",
+			[]string{"convert", "-from", "json", "-to", "serifu"},
+			`{"version": 1, "script": {"pages": [{"title": "P", "panels": [{"id": "1", "items": [{"type": "text", "source": "A", "content": "b"}]}]}]}}`,
+			exitOK,
+			"# P\n- 1\nA: b\n",
+			"",
+		},
+		{
+			"convert csv with invalid rows",
+			[]string{"convert", "-from", "csv", "-to", "serifu"},
+			"page,panel,type,source,content\nP,1,text,A,b\nP,1,balloon,A,c\n",
+			exitError,
+			"# P\n- 1\nA: b\n",
+			"<standard input>:3: unknown type \"balloon\"\n",
+		},
+		{
+			"convert to unknown format",
+			[]string{"convert", "-to", "pdf"},
+			testScript,
+			exitUsage,
+			"",
+			"serifu: unknown format \"pdf\"\n",
+		},
+		{
+			"stats",
+			[]string{"stats"},
+			testScript,
+			exitOK,
+			"<standard input>\n" +
+				"  pages:         1 (1 spreads)\n" +
+				"  panels:        1\n" +
+				"  text lines:    2\n" +
+				"  words:         4\n" +
+				"  sound effects: 1\n" +
+				"  side notes:    1\n" +
+				"  speakers:\n" +
+				"    Shota                2\n",
+			"",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, stdout, stderr := runCommand(tt.args, tt.stdin)
+			if code != tt.wantCode {
+				t.Errorf("run() = %d, want %d", code, tt.wantCode)
+			}
+			if stdout != tt.wantStdout {
+				t.Errorf("stdout = %q, want %q", stdout, tt.wantStdout)
+			}
+			if stderr != tt.wantStderr {
+				t.Errorf("stderr = %q, want %q", stderr, tt.wantStderr)
+			}
+		})
+	}
+}
+
+func TestRun_convertToFile(t *testing.T) {
+	out := filepath.Join(t.TempDir(), "chapter.html")
+	if code, _, stderr := runCommand([]string{"convert", "-o", out}, testScript); code != exitOK {
+		t.Fatalf("run() = %d, %q", code, stderr)
+	}
+	got, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(got), "<em>death match?!?</em>") {
+		t.Errorf("output = %s, want HTML", got)
+	}
+}
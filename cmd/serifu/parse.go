@@ -0,0 +1,53 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+
+	"github.com/aquilax/serifu-go"
+)
+
+var parseCommand = &command{
+	name:  "parse",
+	short: "print scripts as JSON",
+}
+
+func init() {
+	parseCommand.run = runParse
+	commands = append(commands, parseCommand)
+}
+
+func runParse(e *env, args []string) int {
+	fs := newFlagSet(e, parseCommand, "[-bare] [-inline] [files]")
+	bare := fs.Bool("bare", false, "write the script without the versioned envelope")
+	inline := fs.Bool("inline", false, "include the inline markup span tree of text lines")
+	if err := fs.Parse(args); err != nil {
+		return exitUsage
+	}
+	inputs, err := readInputs(e, fs.Args())
+	if err != nil {
+		report(e.stderr, "serifu", err)
+		return exitError
+	}
+	code := exitOK
+	for _, in := range inputs {
+		script, err := serifu.ParseWithOptions(bytes.NewReader(in.data), serifu.ParserOptions{InlineMarkup: *inline})
+		if err != nil {
+			report(e.stderr, in.name, err)
+			code = exitError
+			continue
+		}
+		if *bare {
+			enc := json.NewEncoder(e.stdout)
+			enc.SetIndent("", "  ")
+			err = enc.Encode(script)
+		} else {
+			err = serifu.EncodeJSON(e.stdout, script)
+		}
+		if err != nil {
+			report(e.stderr, in.name, err)
+			code = exitError
+		}
+	}
+	return code
+}
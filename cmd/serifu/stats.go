@@ -0,0 +1,122 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/aquilax/serifu-go"
+)
+
+var statsCommand = &command{
+	name:  "stats",
+	short: "count pages, panels, lines and words",
+}
+
+func init() {
+	statsCommand.run = runStats
+	commands = append(commands, statsCommand)
+}
+
+// stats are the counts collected from a script
+type stats struct {
+	Pages        int            `json:"pages"`
+	Spreads      int            `json:"spreads"`
+	Panels       int            `json:"panels"`
+	TextLines    int            `json:"text_lines"`
+	SoundEffects int            `json:"sound_effects"`
+	SideNotes    int            `json:"side_notes"`
+	Words        int            `json:"words"`
+	Speakers     map[string]int `json:"speakers"`
+}
+
+func collectStats(s *serifu.Script) *stats {
+	st := &stats{Speakers: make(map[string]int)}
+	st.SideNotes += len(s.Notes)
+	for _, p := range s.Pages {
+		st.Pages++
+		if p.IsSpread {
+			st.Spreads++
+		}
+		st.SideNotes += len(p.Notes)
+		for _, pn := range p.Panels {
+			st.Panels++
+			for _, item := range pn.Items {
+				switch i := item.(type) {
+				case *serifu.TextLine:
+					st.TextLines++
+					st.Words += len(strings.Fields(i.Content))
+					if i.Source != "" {
+						st.Speakers[i.Source]++
+					}
+				case *serifu.SoundEffect:
+					st.SoundEffects++
+				case *serifu.SideNote:
+					st.SideNotes++
+				}
+			}
+		}
+	}
+	return st
+}
+
+func (st *stats) writeText(w io.Writer, name string) {
+	fmt.Fprintf(w, "%s\n", name)
+	fmt.Fprintf(w, "  pages:         %d (%d spreads)\n", st.Pages, st.Spreads)
+	fmt.Fprintf(w, "  panels:        %d\n", st.Panels)
+	fmt.Fprintf(w, "  text lines:    %d\n", st.TextLines)
+	fmt.Fprintf(w, "  words:         %d\n", st.Words)
+	fmt.Fprintf(w, "  sound effects: %d\n", st.SoundEffects)
+	fmt.Fprintf(w, "  side notes:    %d\n", st.SideNotes)
+	speakers := make([]string, 0, len(st.Speakers))
+	for s := range st.Speakers {
+		speakers = append(speakers, s)
+	}
+	sort.Slice(speakers, func(i, j int) bool {
+		a, b := speakers[i], speakers[j]
+		if st.Speakers[a] != st.Speakers[b] {
+			return st.Speakers[a] > st.Speakers[b]
+		}
+		return a < b
+	})
+	if len(speakers) > 0 {
+		fmt.Fprintf(w, "  speakers:\n")
+	}
+	for _, s := range speakers {
+		fmt.Fprintf(w, "    %-20s %d\n", s, st.Speakers[s])
+	}
+}
+
+func runStats(e *env, args []string) int {
+	fs := newFlagSet(e, statsCommand, "[-json] [files]")
+	asJSON := fs.Bool("json", false, "write the counts as JSON")
+	if err := fs.Parse(args); err != nil {
+		return exitUsage
+	}
+	inputs, err := readInputs(e, fs.Args())
+	if err != nil {
+		report(e.stderr, "serifu", err)
+		return exitError
+	}
+	code := exitOK
+	for _, in := range inputs {
+		script, err := serifu.Parse(bytes.NewReader(in.data))
+		if err != nil {
+			report(e.stderr, in.name, err)
+			code = exitError
+			continue
+		}
+		st := collectStats(script)
+		if *asJSON {
+			enc := json.NewEncoder(e.stdout)
+			enc.SetIndent("", "  ")
+			enc.Encode(st)
+			continue
+		}
+		st.writeText(e.stdout, in.name)
+	}
+	return code
+}
@@ -0,0 +1,31 @@
+package serifu
+
+import "strings"
+
+const (
+	lineCommentPrefix = "//"
+	blockCommentStart = "/*"
+	blockCommentEnd   = "*/"
+)
+
+// parseBlockComment skips the comment starting at the current line. rest is
+// the text after the start marker. Comments produce no node so a Document
+// keeps them with the blank lines before the next node.
+func (p *parser) parseBlockComment(rest string) *ParseError {
+	if strings.Contains(rest, p.d.BlockCommentEnd) {
+		return nil
+	}
+	lr := p.lr
+	start := lr.trimmedRange().Start
+	var ingested []inputLine
+	for lr.Scan() {
+		ingested = append(ingested, lr.inputLine)
+		if strings.Contains(lr.text, p.d.BlockCommentEnd) {
+			return nil
+		}
+	}
+	if p.opts.Recover {
+		p.resumeAtMarker(ingested)
+	}
+	return p.errorAt(start, UnterminatedBlock, "unterminated comment")
+}
@@ -0,0 +1,95 @@
+package serifu
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const commentInput = `// draft 2, check with the editor
+# PAGE 1
+- 1.1
+  // is it a whisper?
+Shota: Hi
+/* the next panel was cut
+- 1.2
+Shota: Bye
+*/
+- 1.3 /* inline markers are not comments */
+Note: see http://example.com
+`
+
+func TestParse_comments(t *testing.T) {
+	got, err := Parse(strings.NewReader(commentInput))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	want := &Script{
+		Pages: []*Page{
+			{
+				Title: "PAGE 1",
+				Panels: []*Panel{
+					{ID: "1.1", Items: Items{&TextLine{Type: TextLineItemType, Source: "Shota", Content: "Hi"}}},
+					{ID: "1.3 /* inline markers are not comments */", Items: Items{
+						&TextLine{Type: TextLineItemType, Source: "Note", Content: "see http://example.com"},
+					}},
+				},
+			},
+		},
+	}
+	if got := withoutRanges(got); !reflect.DeepEqual(got, want) {
+		t.Errorf("Parse() = %v, want %v", got, want)
+	}
+
+	doc, err := ParseDocument(strings.NewReader(commentInput), ParserOptions{})
+	if err != nil {
+		t.Fatalf("ParseDocument() error = %v", err)
+	}
+	if got := doc.String(); got != commentInput {
+		t.Errorf("Document.String() = %q, want %q", got, commentInput)
+	}
+}
+
+func TestParse_unterminatedComment(t *testing.T) {
+	input := "# PAGE 1\n/* cut\n- 1.1\n# PAGE 2\n- 2.1\n"
+	_, err := Parse(strings.NewReader(input))
+	var pe *ParseError
+	if !errors.As(err, &pe) || pe.Kind != UnterminatedBlock || pe.Pos.Line != 2 {
+		t.Errorf("Parse() error = %v, want unterminated block on line 2", err)
+	}
+
+	got, err := ParseWithOptions(strings.NewReader(input), ParserOptions{Recover: true})
+	if err == nil {
+		t.Errorf("ParseWithOptions() error = nil, want error")
+	}
+	if len(got.Pages) != 2 || len(got.Pages[0].Panels) != 1 {
+		t.Errorf("ParseWithOptions() = %v, want parsing to resume at the panel", got)
+	}
+}
+
+func TestFormatDocument_keepsComments(t *testing.T) {
+	doc, err := ParseDocument(strings.NewReader(commentInput), ParserOptions{})
+	if err != nil {
+		t.Fatalf("ParseDocument() error = %v", err)
+	}
+	var b strings.Builder
+	if err := FormatDocument(&b, doc, FormatOptions{Indent: "  "}); err != nil {
+		t.Fatalf("FormatDocument() error = %v", err)
+	}
+	want := `// draft 2, check with the editor
+# PAGE 1
+  - 1.1
+  // is it a whisper?
+    Shota: Hi
+/* the next panel was cut
+- 1.2
+Shota: Bye
+*/
+  - 1.3 /* inline markers are not comments */
+    Note: see http://example.com
+`
+	if got := b.String(); got != want {
+		t.Errorf("FormatDocument() = %q, want %q", got, want)
+	}
+}
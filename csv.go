@@ -0,0 +1,429 @@
+package serifu
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// CSVColumn is a column of the spreadsheet layout of EncodeCSV
+type CSVColumn string
+
+const (
+	// CSVRowType is the type of the row: an item type, or page, panel,
+	// pageNote, scriptNote or meta
+	CSVRowType CSVColumn = "type"
+	// CSVPageTitle is the title of the page of the row
+	CSVPageTitle CSVColumn = "page"
+	// CSVSpread is true when the page is a spread
+	CSVSpread CSVColumn = "spread"
+	// CSVPanelID is the ID of the panel of the row
+	CSVPanelID CSVColumn = "panel"
+	// CSVSource is the source of text lines and the key of meta rows
+	CSVSource CSVColumn = "source"
+	// CSVStyle is the style of text lines
+	CSVStyle CSVColumn = "style"
+	// CSVKind is the kind of text lines, empty for dialogue
+	CSVKind CSVColumn = "kind"
+	// CSVConnected is true for connected text lines
+	CSVConnected CSVColumn = "connected"
+	// CSVPreFormatted is true for pre-formatted text lines
+	CSVPreFormatted CSVColumn = "pre_formatted"
+	// CSVContent is the content of text lines and notes, the value of meta
+	// rows and the text of sound effect lines
+	CSVContent CSVColumn = "content"
+	// CSVSFXName is the name of sound effects
+	CSVSFXName CSVColumn = "sfx_name"
+	// CSVSFXTransliteration is the romanization of sound effects
+	CSVSFXTransliteration CSVColumn = "sfx_transliteration"
+	// CSVSFXOriginal is the original script of sound effects
+	CSVSFXOriginal CSVColumn = "sfx_original"
+	// CSVSFXPlacement is the placement of sound effects
+	CSVSFXPlacement CSVColumn = "sfx_placement"
+	// CSVAttrs is the attribute list of items
+	CSVAttrs CSVColumn = "attrs"
+)
+
+// row types of the layout besides the item types
+const (
+	csvPageRow       = "page"
+	csvPanelRow      = "panel"
+	csvPageNoteRow   = "pageNote"
+	csvScriptNoteRow = "scriptNote"
+	csvMetaRow       = "meta"
+)
+
+// DefaultCSVColumns is the layout used when CSVOptions.Columns is empty
+var DefaultCSVColumns = []CSVColumn{
+	CSVPageTitle, CSVSpread, CSVPanelID, CSVRowType, CSVSource, CSVStyle,
+	CSVKind, CSVConnected, CSVPreFormatted, CSVContent, CSVSFXName,
+	CSVSFXTransliteration, CSVSFXOriginal, CSVSFXPlacement, CSVAttrs,
+}
+
+// CSVOptions controls EncodeCSV and DecodeCSV
+type CSVOptions struct {
+	// Comma is the field delimiter, ',' when zero. Use '\t' for TSV.
+	Comma rune
+	// Columns are the columns in order. When reading a file with a header
+	// row the header decides the layout.
+	Columns []CSVColumn
+	// NoHeader leaves out the header row when writing and reads the first
+	// row as data
+	NoHeader bool
+}
+
+func (o CSVOptions) columns() []CSVColumn {
+	if len(o.Columns) == 0 {
+		return DefaultCSVColumns
+	}
+	return o.Columns
+}
+
+func (o CSVOptions) comma() rune {
+	if o.Comma == 0 {
+		return ','
+	}
+	return o.Comma
+}
+
+// EncodeCSV writes s to w as a spreadsheet with one row per item. Pages
+// without panels and panels without items get a row of their own.
+// Attributes of pages and panels are not written.
+func EncodeCSV(w io.Writer, s *Script, opts CSVOptions) error {
+	columns := opts.columns()
+	cw := csv.NewWriter(w)
+	cw.Comma = opts.comma()
+	if !opts.NoHeader {
+		header := make([]string, len(columns))
+		for i, c := range columns {
+			header[i] = string(c)
+		}
+		cw.Write(header)
+	}
+	write := func(values map[CSVColumn]string) {
+		record := make([]string, len(columns))
+		for i, c := range columns {
+			record[i] = values[c]
+		}
+		cw.Write(record)
+	}
+	if s.Meta != nil {
+		for _, f := range s.Meta.Fields() {
+			write(map[CSVColumn]string{CSVRowType: csvMetaRow, CSVSource: f[0], CSVContent: f[1]})
+		}
+	}
+	for _, n := range s.Notes {
+		write(map[CSVColumn]string{CSVRowType: csvScriptNoteRow, CSVContent: n.Content})
+	}
+	for _, p := range s.Pages {
+		page := map[CSVColumn]string{CSVPageTitle: p.Title, CSVSpread: csvBool(p.IsSpread)}
+		row := func(values map[CSVColumn]string) {
+			for k, v := range page {
+				values[k] = v
+			}
+			write(values)
+		}
+		for _, n := range p.Notes {
+			row(map[CSVColumn]string{CSVRowType: csvPageNoteRow, CSVContent: n.Content})
+		}
+		if len(p.Panels) == 0 && len(p.Notes) == 0 {
+			row(map[CSVColumn]string{CSVRowType: csvPageRow})
+		}
+		for _, pn := range p.Panels {
+			if len(pn.Items) == 0 {
+				row(map[CSVColumn]string{CSVRowType: csvPanelRow, CSVPanelID: pn.ID})
+			}
+			for _, item := range pn.Items {
+				values := csvItem(item)
+				values[CSVPanelID] = pn.ID
+				row(values)
+			}
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// csvItem returns the values of the row of an item
+func csvItem(item Item) map[CSVColumn]string {
+	values := map[CSVColumn]string{CSVRowType: string(item.Kind())}
+	switch i := item.(type) {
+	case *TextLine:
+		values[CSVSource] = i.Source
+		values[CSVStyle] = i.Style
+		values[CSVKind] = string(i.LineKind)
+		values[CSVConnected] = csvBool(i.Connected)
+		values[CSVPreFormatted] = csvBool(i.IsPreFormatted)
+		values[CSVContent] = i.Content
+		values[CSVAttrs] = formatAttrs(i.Attrs)
+	case *SoundEffect:
+		values[CSVContent] = formatSoundEffectText(i)
+		values[CSVSFXName] = i.Name
+		values[CSVSFXTransliteration] = i.Transliteration
+		values[CSVSFXOriginal] = i.Original
+		values[CSVSFXPlacement] = i.Placement
+		values[CSVAttrs] = formatAttrs(i.Attrs)
+	case *SideNote:
+		values[CSVContent] = i.Content
+	}
+	return values
+}
+
+func csvBool(b bool) string {
+	if b {
+		return "true"
+	}
+	return ""
+}
+
+// CSVRowError is a spreadsheet row DecodeCSV could not read
+type CSVRowError struct {
+	// Line is the 1-based line the row starts on
+	Line int
+	Msg  string
+}
+
+func (e *CSVRowError) Error() string {
+	return fmt.Sprintf("line %d: %s", e.Line, e.Msg)
+}
+
+// CSVRowErrors are the rows skipped by DecodeCSV
+type CSVRowErrors []*CSVRowError
+
+func (l CSVRowErrors) Error() string {
+	msgs := make([]string, len(l))
+	for i, e := range l {
+		msgs[i] = e.Error()
+	}
+	return strings.Join(msgs, "\n")
+}
+
+// DecodeCSV reads a script written by EncodeCSV. Consecutive rows with the
+// same page title and panel ID belong to the same page and panel. Line
+// breaks in cells are replaced by spaces except in pre-formatted content.
+// The type column can be left empty for text lines and for sound effects
+// with a name. Invalid rows are skipped and returned as CSVRowErrors
+// together with the rest of the script.
+func DecodeCSV(r io.Reader, opts CSVOptions) (*Script, error) {
+	cr := csv.NewReader(r)
+	cr.Comma = opts.comma()
+	cr.FieldsPerRecord = -1
+	d := &csvDecoder{script: &Script{Pages: make([]*Page, 0)}, columns: opts.columns()}
+	header := !opts.NoHeader
+	for {
+		record, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		var pe *csv.ParseError
+		if errors.As(err, &pe) {
+			d.errors = append(d.errors, &CSVRowError{Line: pe.StartLine, Msg: pe.Err.Error()})
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if header {
+			header = false
+			d.columns = make([]CSVColumn, len(record))
+			for i, name := range record {
+				d.columns[i] = CSVColumn(strings.ToLower(strings.TrimSpace(name)))
+			}
+			continue
+		}
+		if msg := d.row(record); msg != "" {
+			line, _ := cr.FieldPos(0)
+			d.errors = append(d.errors, &CSVRowError{Line: line, Msg: msg})
+		}
+	}
+	if len(d.errors) > 0 {
+		return d.script, d.errors
+	}
+	return d.script, nil
+}
+
+// csvDecoder builds a script from spreadsheet rows
+type csvDecoder struct {
+	script  *Script
+	columns []CSVColumn
+	page    *Page
+	panel   *Panel
+	errors  CSVRowErrors
+}
+
+func (d *csvDecoder) hasColumn(c CSVColumn) bool {
+	for _, col := range d.columns {
+		if col == c {
+			return true
+		}
+	}
+	return false
+}
+
+// row adds a row to the script. It returns why the row is invalid or an
+// empty string.
+func (d *csvDecoder) row(record []string) string {
+	if len(record) > len(d.columns) {
+		return fmt.Sprintf("%d fields, want at most %d", len(record), len(d.columns))
+	}
+	values := make(map[CSVColumn]string)
+	empty := true
+	for i, v := range record {
+		values[d.columns[i]] = v
+		empty = empty && strings.TrimSpace(v) == ""
+	}
+	if empty {
+		return ""
+	}
+	var bools [3]bool
+	for i, c := range []CSVColumn{CSVSpread, CSVConnected, CSVPreFormatted} {
+		b, ok := parseCSVBool(values[c])
+		if !ok {
+			return fmt.Sprintf("invalid %s value %q", c, values[c])
+		}
+		bools[i] = b
+	}
+	spread, connected, preFormatted := bools[0], bools[1], bools[2]
+	for c, v := range values {
+		if c != CSVContent || !preFormatted {
+			values[c] = csvLine(v)
+		}
+	}
+	attrs, ok := parseCSVAttrs(values[CSVAttrs])
+	if !ok {
+		return fmt.Sprintf("invalid attrs %q", values[CSVAttrs])
+	}
+	typ := strings.TrimSpace(values[CSVRowType])
+	if typ == "" {
+		typ = string(TextLineItemType)
+		if values[CSVSFXName] != "" {
+			typ = string(SoundEffectItemType)
+		}
+	}
+
+	switch typ {
+	case csvMetaRow:
+		if d.script.Meta == nil {
+			d.script.Meta = &Meta{}
+		}
+		d.script.Meta.Set(values[CSVSource], values[CSVContent])
+		return ""
+	case csvScriptNoteRow:
+		d.script.Notes = append(d.script.Notes, &SideNote{Type: SideNoteItemType, Content: values[CSVContent]})
+		return ""
+	case csvPageRow, csvPanelRow, csvPageNoteRow, string(TextLineItemType), string(SoundEffectItemType), string(SideNoteItemType):
+	default:
+		return fmt.Sprintf("unknown type %q", typ)
+	}
+
+	title := values[CSVPageTitle]
+	if d.page == nil || typ == csvPageRow || title != d.page.Title || spread != d.page.IsSpread {
+		d.page = &Page{Title: title, IsSpread: spread}
+		d.panel = nil
+		d.script.Pages = append(d.script.Pages, d.page)
+	}
+	switch typ {
+	case csvPageRow:
+		return ""
+	case csvPageNoteRow:
+		d.page.Notes = append(d.page.Notes, &SideNote{Type: SideNoteItemType, Content: values[CSVContent]})
+		return ""
+	}
+	id := values[CSVPanelID]
+	if d.panel == nil || typ == csvPanelRow || id != d.panel.ID {
+		d.panel = &Panel{ID: id}
+		d.page.Panels = append(d.page.Panels, d.panel)
+	}
+
+	var item Item
+	switch ItemType(typ) {
+	case TextLineItemType:
+		kind, ok := parseCSVLineKind(values[CSVKind])
+		if !ok {
+			return fmt.Sprintf("unknown kind %q", values[CSVKind])
+		}
+		item = &TextLine{
+			Type:           TextLineItemType,
+			Source:         values[CSVSource],
+			Style:          values[CSVStyle],
+			LineKind:       kind,
+			Connected:      connected,
+			IsPreFormatted: preFormatted,
+			Content:        values[CSVContent],
+			Attrs:          attrs,
+		}
+	case SoundEffectItemType:
+		var se *SoundEffect
+		if d.hasColumn(CSVSFXName) {
+			se = &SoundEffect{
+				Type:            SoundEffectItemType,
+				Name:            values[CSVSFXName],
+				Transliteration: values[CSVSFXTransliteration],
+				Original:        values[CSVSFXOriginal],
+				Placement:       values[CSVSFXPlacement],
+			}
+		} else {
+			se = parseSoundEffect(values[CSVContent])
+		}
+		se.Attrs = attrs
+		item = se
+	case SideNoteItemType:
+		item = &SideNote{Type: SideNoteItemType, Content: values[CSVContent]}
+	default:
+		return ""
+	}
+	d.panel.Items = append(d.panel.Items, item)
+	return ""
+}
+
+// csvLine joins the lines of a cell with spaces as only pre-formatted text
+// can span lines in a script
+func csvLine(s string) string {
+	if !strings.Contains(s, "\n") {
+		return s
+	}
+	var lines []string
+	for _, l := range strings.Split(s, "\n") {
+		if l = strings.TrimSpace(l); l != "" {
+			lines = append(lines, l)
+		}
+	}
+	return strings.Join(lines, " ")
+}
+
+func parseCSVBool(s string) (bool, bool) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "", "false", "no", "0", "n":
+		return false, true
+	case "true", "yes", "1", "y", "x":
+		return true, true
+	}
+	return false, false
+}
+
+func parseCSVAttrs(s string) (Attrs, bool) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return nil, true
+	}
+	if !strings.HasPrefix(s, attrsStart) {
+		s = attrsStart + s + attrsEnd
+	}
+	rest, attrs := cutAttrs(s)
+	return attrs, attrs != nil && rest == ""
+}
+
+func parseCSVLineKind(s string) (LineKind, bool) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if s == "" || s == DialogueLine.String() {
+		return DialogueLine, true
+	}
+	for _, k := range lineKinds {
+		if s == string(k) {
+			return k, true
+		}
+	}
+	return DialogueLine, false
+}
@@ -0,0 +1,182 @@
+package serifu
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const csvInput = `---
+series: Moriking
+chapter: 31
+Cover Artist: Someone
+---
+
+! French edition
+
+# PAGE 1
+! mirrored
+- 1.1
+Shota/Sharp (thought): A _death_ "match", {死闘|shitou} {size=large}
+* BAM (バン | ban) [top] {color=red}
+! check the font
+(caption): Later
+& Aki (whisper): psst
+- 1.2
+
+## PAGE 2-3
+- 2.1
+Sign:/=
+Menu:
+	Beer
+=/
+
+# PAGE 4
+`
+
+func TestDecodeCSV_roundTrip(t *testing.T) {
+	want, err := Parse(strings.NewReader(csvInput))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	for _, opts := range []CSVOptions{
+		{},
+		{Comma: '\t'},
+		{NoHeader: true},
+		{Columns: []CSVColumn{CSVContent, CSVRowType, CSVPanelID, CSVPageTitle, CSVSpread, CSVSource, CSVStyle, CSVKind, CSVConnected, CSVPreFormatted, CSVAttrs}},
+	} {
+		var b bytes.Buffer
+		if err := EncodeCSV(&b, want, opts); err != nil {
+			t.Fatalf("EncodeCSV(%+v) error = %v", opts, err)
+		}
+		got, err := DecodeCSV(&b, opts)
+		if err != nil {
+			t.Fatalf("DecodeCSV(%+v) error = %v", opts, err)
+		}
+		if !reflect.DeepEqual(got, withoutRanges(want)) {
+			t.Errorf("DecodeCSV(%+v) =\n%v\nwant\n%v", opts, got, want)
+		}
+	}
+}
+
+func TestEncodeCSV(t *testing.T) {
+	s, err := Parse(strings.NewReader("# PAGE 1\n- 1\nShota/Sharp: Hi, you\n* BAM (ban)\n- 2\n"))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	var b bytes.Buffer
+	opts := CSVOptions{Columns: []CSVColumn{CSVPageTitle, CSVPanelID, CSVRowType, CSVSource, CSVContent, CSVSFXName}}
+	if err := EncodeCSV(&b, s, opts); err != nil {
+		t.Fatalf("EncodeCSV() error = %v", err)
+	}
+	want := "page,panel,type,source,content,sfx_name\n" +
+		"PAGE 1,1,text,Shota,\"Hi, you\",\n" +
+		"PAGE 1,1,soundEffect,,BAM (ban),BAM\n" +
+		"PAGE 1,2,panel,,,\n"
+	if got := b.String(); got != want {
+		t.Errorf("EncodeCSV() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestDecodeCSV_lineBreaks(t *testing.T) {
+	input := "page,panel,type,source,pre_formatted,content\n" +
+		"P,1,text,A,,\"line one\r\n  line two\"\n" +
+		"P,1,sideNote,,,\"check\nthis\"\n" +
+		"P,1,text,Sign,true,\"Menu\nBeer\n\"\n"
+	s, err := DecodeCSV(strings.NewReader(input), CSVOptions{})
+	if err != nil {
+		t.Fatalf("DecodeCSV() error = %v", err)
+	}
+	var b bytes.Buffer
+	if err := Format(&b, s, FormatOptions{}); err != nil {
+		t.Fatalf("Format() error = %v", err)
+	}
+	want := "# P\n- 1\nA: line one line two\n! check this\nSign:/=\nMenu\nBeer\n=/\n"
+	if got := b.String(); got != want {
+		t.Errorf("Format() =\n%s\nwant\n%s", got, want)
+	}
+	got, err := Parse(&b)
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if !reflect.DeepEqual(withoutRanges(got), s) {
+		t.Errorf("Parse() =\n%v\nwant\n%v", got, s)
+	}
+}
+
+func TestDecodeCSV(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    *Script
+		wantErr string
+	}{
+		{
+			"types from columns",
+			"Page,Panel,Source,Content,SFX_Name\nP,1,A,hi,\nP,1,,,BAM\nP,2,B,yo,\n",
+			&Script{Pages: []*Page{{Title: "P", Panels: []*Panel{
+				{ID: "1", Items: []Item{
+					&TextLine{Type: TextLineItemType, Source: "A", Content: "hi"},
+					&SoundEffect{Type: SoundEffectItemType, Name: "BAM"},
+				}},
+				{ID: "2", Items: []Item{
+					&TextLine{Type: TextLineItemType, Source: "B", Content: "yo"},
+				}},
+			}}}},
+			"",
+		},
+		{
+			"sound effect from content",
+			"page,panel,type,content\nP,1,soundEffect,DON (ドン | don) [left]\n",
+			&Script{Pages: []*Page{{Title: "P", Panels: []*Panel{
+				{ID: "1", Items: []Item{
+					&SoundEffect{Type: SoundEffectItemType, Name: "DON", Original: "ドン", Transliteration: "don", Placement: "left"},
+				}},
+			}}}},
+			"",
+		},
+		{
+			"invalid rows",
+			"page,panel,type,spread,kind,attrs,content\n" +
+				"P,1,text,,,,ok\n" +
+				"P,1,balloon,,,,a\n" +
+				"P,1,text,maybe,,,b\n" +
+				"P,1,text,,shout,,c\n" +
+				"P,1,text,,,size,d\n" +
+				"P,1,text,,,,e,extra\n" +
+				"\n" +
+				"P,1,\"text,,,,\"f\n" +
+				"P,1,text,,,,\"multi\nline\"\n" +
+				"P,1,bad,,,,g\n",
+			&Script{Pages: []*Page{{Title: "P", Panels: []*Panel{
+				{ID: "1", Items: []Item{
+					&TextLine{Type: TextLineItemType, Content: "ok"},
+					&TextLine{Type: TextLineItemType, Content: "multi line"},
+				}},
+			}}}},
+			"line 3: unknown type \"balloon\"\n" +
+				"line 4: invalid spread value \"maybe\"\n" +
+				"line 5: unknown kind \"shout\"\n" +
+				"line 6: invalid attrs \"size\"\n" +
+				"line 7: 8 fields, want at most 7\n" +
+				"line 9: extraneous or missing \" in quoted-field\n" +
+				"line 12: unknown type \"bad\"",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := DecodeCSV(strings.NewReader(tt.input), CSVOptions{})
+			var gotErr string
+			if err != nil {
+				gotErr = err.Error()
+			}
+			if gotErr != tt.wantErr {
+				t.Errorf("DecodeCSV() error =\n%s\nwant\n%s", gotErr, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("DecodeCSV() =\n%v\nwant\n%v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,66 @@
+package serifu
+
+import "io"
+
+// Decoder reads a script page by page. Every page is returned as soon as
+// the next one starts so only one page is kept in memory at a time.
+type Decoder struct {
+	p    *parser
+	done bool
+	err  error
+}
+
+// NewDecoder returns a decoder reading from r
+func NewDecoder(r io.Reader) *Decoder {
+	return NewDecoderWithOptions(r, ParserOptions{})
+}
+
+// NewDecoderWithOptions returns a decoder reading from r using opts
+func NewDecoderWithOptions(r io.Reader, opts ParserOptions) *Decoder {
+	return &Decoder{p: newParser(r, opts)}
+}
+
+// Next returns the next complete page. It returns io.EOF after the last
+// page. Invalid input is reported with the same errors as Parse; in
+// recovering mode they are collected and available from Errors.
+func (d *Decoder) Next() (*Page, error) {
+	if d.err != nil {
+		return nil, d.err
+	}
+	for {
+		pages := d.p.script.Pages
+		if len(pages) > 1 || (d.done && len(pages) == 1) {
+			page := pages[0]
+			pages[0] = nil
+			d.p.script.Pages = pages[1:]
+			return page, nil
+		}
+		if d.done {
+			return nil, io.EOF
+		}
+		ok, err := d.p.scan()
+		if err != nil {
+			d.err = err
+			d.p.script.Pages = nil
+			return nil, err
+		}
+		d.done = !ok
+	}
+}
+
+// Errors returns the problems found so far in recovering mode
+func (d *Decoder) Errors() ErrorList {
+	return d.p.errors
+}
+
+// Notes returns the script notes read so far. They are complete once the
+// first page was returned.
+func (d *Decoder) Notes() []*SideNote {
+	return d.p.script.Notes
+}
+
+// Meta returns the front matter of the script. It is available once the
+// first page was returned.
+func (d *Decoder) Meta() *Meta {
+	return d.p.script.Meta
+}
@@ -0,0 +1,80 @@
+package serifu
+
+import (
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDecoder_Next(t *testing.T) {
+	tests := []struct {
+		name       string
+		input      string
+		wantTitles []string
+		wantErr    bool
+	}{
+		{"empty", "", nil, false},
+		{"single page", "# PAGE 1\n- 1.1\nShota: Hi\n", []string{"PAGE 1"}, false},
+		{"many pages", "# PAGE 1\n## PAGE 2\n- 2.1\n# PAGE 3\n", []string{"PAGE 1", "PAGE 2", "PAGE 3"}, false},
+		{"error after first page", "# PAGE 1\n# PAGE 2\nbad\n# PAGE 3\n", []string{"PAGE 1"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := NewDecoder(strings.NewReader(tt.input))
+			var titles []string
+			var err error
+			for {
+				var page *Page
+				page, err = d.Next()
+				if err != nil {
+					break
+				}
+				titles = append(titles, page.Title)
+			}
+			if (err != io.EOF) != tt.wantErr {
+				t.Errorf("Decoder.Next() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(titles, tt.wantTitles) {
+				t.Errorf("titles = %v, want %v", titles, tt.wantTitles)
+			}
+		})
+	}
+}
+
+func TestDecoder_Next_matchesParse(t *testing.T) {
+	want, err := Parse(strings.NewReader(formatInput))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	d := NewDecoder(strings.NewReader(formatInput))
+	for i, wantPage := range want.Pages {
+		page, err := d.Next()
+		if err != nil {
+			t.Fatalf("Decoder.Next() error = %v", err)
+		}
+		if !reflect.DeepEqual(page, wantPage) {
+			t.Errorf("page %d = %v, want %v", i, page, wantPage)
+		}
+	}
+	if _, err := d.Next(); err != io.EOF {
+		t.Errorf("Decoder.Next() error = %v, want io.EOF", err)
+	}
+	if !reflect.DeepEqual(d.Notes(), want.Notes) {
+		t.Errorf("Decoder.Notes() = %v, want %v", d.Notes(), want.Notes)
+	}
+}
+
+func TestDecoder_Errors(t *testing.T) {
+	d := NewDecoderWithOptions(strings.NewReader("# PAGE 1\nbad\n# PAGE 2\n"), ParserOptions{Recover: true})
+	n := 0
+	for {
+		if _, err := d.Next(); err != nil {
+			break
+		}
+		n++
+	}
+	if n != 2 || len(d.Errors()) != 1 {
+		t.Errorf("pages = %d, errors = %v, want 2 pages and 1 error", n, d.Errors())
+	}
+}
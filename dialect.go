@@ -0,0 +1,71 @@
+package serifu
+
+// Dialect is the set of markers of a Serifu variant. Fields left empty use
+// the marker of DefaultDialect.
+type Dialect struct {
+	PageSpreadPrefix       string `json:"page_spread_prefix,omitempty"`
+	PagePrefix             string `json:"page_prefix,omitempty"`
+	PanelPrefix            string `json:"panel_prefix,omitempty"`
+	SoundPrefix            string `json:"sound_prefix,omitempty"`
+	SideNotePrefix         string `json:"side_note_prefix,omitempty"`
+	TextLineSeparator      string `json:"text_line_separator,omitempty"`
+	StyleSeparator         string `json:"style_separator,omitempty"`
+	PreFormattedBlockStart string `json:"pre_formatted_block_start,omitempty"`
+	PreFormattedBlockEnd   string `json:"pre_formatted_block_end,omitempty"`
+	FrontMatterDelimiter   string `json:"front_matter_delimiter,omitempty"`
+	LineCommentPrefix      string `json:"line_comment_prefix,omitempty"`
+	BlockCommentStart      string `json:"block_comment_start,omitempty"`
+	BlockCommentEnd        string `json:"block_comment_end,omitempty"`
+	ConnectedPrefix        string `json:"connected_prefix,omitempty"`
+}
+
+// DefaultDialect is the standard Serifu markup
+var DefaultDialect = Dialect{
+	PageSpreadPrefix:       pageSpreadPrefix,
+	PagePrefix:             pagePrefix,
+	PanelPrefix:            panelPrefix,
+	SoundPrefix:            soundPrefix,
+	SideNotePrefix:         sideNotePrefix,
+	TextLineSeparator:      textLineSeparator,
+	StyleSeparator:         styleSeparator,
+	PreFormattedBlockStart: preFormattedBlockStart,
+	PreFormattedBlockEnd:   preFormattedBlockEnd,
+	FrontMatterDelimiter:   frontMatterDelimiter,
+	LineCommentPrefix:      lineCommentPrefix,
+	BlockCommentStart:      blockCommentStart,
+	BlockCommentEnd:        blockCommentEnd,
+	ConnectedPrefix:        connectedPrefix,
+}
+
+// complete returns a copy of d with the empty markers set to the defaults.
+// A nil dialect is the default one.
+func (d *Dialect) complete() *Dialect {
+	c := DefaultDialect
+	if d == nil {
+		return &c
+	}
+	for _, f := range []struct {
+		dst *string
+		src string
+	}{
+		{&c.PageSpreadPrefix, d.PageSpreadPrefix},
+		{&c.PagePrefix, d.PagePrefix},
+		{&c.PanelPrefix, d.PanelPrefix},
+		{&c.SoundPrefix, d.SoundPrefix},
+		{&c.SideNotePrefix, d.SideNotePrefix},
+		{&c.TextLineSeparator, d.TextLineSeparator},
+		{&c.StyleSeparator, d.StyleSeparator},
+		{&c.PreFormattedBlockStart, d.PreFormattedBlockStart},
+		{&c.PreFormattedBlockEnd, d.PreFormattedBlockEnd},
+		{&c.FrontMatterDelimiter, d.FrontMatterDelimiter},
+		{&c.LineCommentPrefix, d.LineCommentPrefix},
+		{&c.BlockCommentStart, d.BlockCommentStart},
+		{&c.BlockCommentEnd, d.BlockCommentEnd},
+		{&c.ConnectedPrefix, d.ConnectedPrefix},
+	} {
+		if f.src != "" {
+			*f.dst = f.src
+		}
+	}
+	return &c
+}
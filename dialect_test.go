@@ -0,0 +1,64 @@
+package serifu
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseWithOptions_dialect(t *testing.T) {
+	dialect := &Dialect{
+		SoundPrefix:       "~",
+		SideNotePrefix:    ">",
+		TextLineSeparator: "：",
+	}
+	input := "# PAGE 1\n- 1.1\nショウタ/Sharp：こんにちは\n~ gasp (haa)\n> note\n! not a note：text\n"
+	got, err := ParseWithOptions(strings.NewReader(input), ParserOptions{Dialect: dialect})
+	if err != nil {
+		t.Fatalf("ParseWithOptions() error = %v", err)
+	}
+	want := Items{
+		&TextLine{Type: TextLineItemType, Source: "ショウタ", Style: "Sharp", Content: "こんにちは"},
+		&SoundEffect{Type: SoundEffectItemType, Name: "gasp", Transliteration: "haa"},
+		&SideNote{Type: SideNoteItemType, Content: "note"},
+		&TextLine{Type: TextLineItemType, Source: "! not a note", Content: "text"},
+	}
+	if items := withoutRanges(got).Pages[0].Panels[0].Items; !reflect.DeepEqual(items, want) {
+		t.Errorf("Items = %v, want %v", items, want)
+	}
+
+	var b strings.Builder
+	if err := Format(&b, got, FormatOptions{Dialect: dialect}); err != nil {
+		t.Fatalf("Format() error = %v", err)
+	}
+	wantText := "# PAGE 1\n- 1.1\nショウタ/Sharp： こんにちは\n~ gasp (haa)\n> note\n! not a note： text\n"
+	if b.String() != wantText {
+		t.Errorf("Format() = %q, want %q", b.String(), wantText)
+	}
+}
+
+func TestFormat_convertDialect(t *testing.T) {
+	s, err := Parse(strings.NewReader("## PAGE 1\n- 1.1\nSign:/=a\nb=/\n"))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	dialect := &Dialect{
+		PageSpreadPrefix:       "@@",
+		PreFormattedBlockStart: "<<",
+		PreFormattedBlockEnd:   ">>",
+	}
+	var b strings.Builder
+	if err := Format(&b, s, FormatOptions{Dialect: dialect}); err != nil {
+		t.Fatalf("Format() error = %v", err)
+	}
+	if want := "@@ PAGE 1\n- 1.1\nSign:<<\na\nb>>\n"; b.String() != want {
+		t.Errorf("Format() = %q, want %q", b.String(), want)
+	}
+	got, err := ParseWithOptions(strings.NewReader(b.String()), ParserOptions{Dialect: dialect})
+	if err != nil {
+		t.Fatalf("ParseWithOptions() error = %v", err)
+	}
+	if !reflect.DeepEqual(withoutRanges(got), withoutRanges(s)) {
+		t.Errorf("ParseWithOptions() = %v, want %v", got, s)
+	}
+}
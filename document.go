@@ -0,0 +1,149 @@
+package serifu
+
+import (
+	"io"
+	"strings"
+	"unicode"
+)
+
+// Document is a script together with the exact text it was parsed from.
+// Writing a document reproduces the input byte for byte, except for the
+// nodes changed since parsing which are printed again in place. Blank lines,
+// indentation and spacing of the untouched lines are kept as they were.
+type Document struct {
+	Script *Script
+
+	dialect *Dialect                 // dialect of the input
+	nodes   map[interface{}]*rawNode // raw text of every parsed node
+	trivia  string                   // input not attached to a node yet
+	newline string                   // line terminator used by the input
+}
+
+// rawNode is the input a node was parsed from
+type rawNode struct {
+	leading string // blank and skipped lines before the node
+	indent  string // white space before the node markup
+	text    string // the node markup including the line terminator
+	eol     string // line terminator of the last line of the node
+	canon   string // canonical markup of the node when it was parsed
+}
+
+// ParseDocument parses the input like ParseWithOptions and keeps the raw
+// text needed to print it back unchanged. In recovering mode the lines
+// which could not be parsed are kept as they are.
+func ParseDocument(r io.Reader, opts ParserOptions) (*Document, error) {
+	p := newParser(r, opts)
+	p.lr.keep = true
+	p.doc = &Document{
+		dialect: p.d,
+		nodes:   make(map[interface{}]*rawNode),
+	}
+	script, err := p.parse()
+	if script == nil {
+		return nil, err
+	}
+	return p.doc, err
+}
+
+// record attaches the raw text of a parsed line to its node. Lines which
+// did not produce a node are kept for the next one.
+func (d *Document) record(node interface{}, raw string) {
+	if d.newline == "" && strings.HasSuffix(raw, "\n") {
+		d.newline = "\n"
+		if strings.HasSuffix(raw, "\r\n") {
+			d.newline = "\r\n"
+		}
+	}
+	if node == nil {
+		d.trivia += raw
+		return
+	}
+	text := strings.TrimLeftFunc(raw, unicode.IsSpace)
+	rn := &rawNode{
+		leading: d.trivia,
+		indent:  raw[:len(raw)-len(text)],
+		text:    text,
+		eol:     raw[len(strings.TrimRight(raw, "\r\n")):],
+	}
+	d.trivia = ""
+	switch n := node.(type) {
+	case *Meta:
+		rn.canon = d.dialect.frontMatter(n)
+	case *Page:
+		rn.canon = d.dialect.pageLine(n)
+	case *Panel:
+		rn.canon = d.dialect.panelLine(n)
+	case Item:
+		rn.canon = d.dialect.item(n)
+	}
+	d.nodes[node] = rn
+}
+
+func (d *Document) finish(script *Script) {
+	d.Script = script
+	if d.newline == "" {
+		d.newline = "\n"
+	}
+}
+
+// WriteTo writes the document to w
+func (d *Document) WriteTo(w io.Writer) (int64, error) {
+	n, err := io.WriteString(w, d.String())
+	return int64(n), err
+}
+
+func (d *Document) String() string {
+	dw := &documentWriter{doc: d}
+	if meta := d.Script.Meta; meta != nil {
+		dw.write(d.nodes[meta], d.dialect.frontMatter(meta))
+	}
+	for _, note := range d.Script.Notes {
+		dw.write(d.nodes[note], d.dialect.item(note))
+	}
+	for _, page := range d.Script.Pages {
+		dw.write(d.nodes[page], d.dialect.pageLine(page))
+		for _, note := range page.Notes {
+			dw.write(d.nodes[note], d.dialect.item(note))
+		}
+		for _, panel := range page.Panels {
+			dw.write(d.nodes[panel], d.dialect.panelLine(panel))
+			for _, item := range panel.Items {
+				dw.write(d.nodes[item], d.dialect.item(item))
+			}
+		}
+	}
+	dw.writeRaw(d.trivia)
+	return dw.b.String()
+}
+
+// documentWriter prints the nodes of a document
+type documentWriter struct {
+	doc *Document
+	b   strings.Builder
+}
+
+// writeRaw writes s making sure it starts on a new line
+func (dw *documentWriter) writeRaw(s string) {
+	if s == "" {
+		return
+	}
+	if dw.b.Len() > 0 && !strings.HasSuffix(dw.b.String(), "\n") {
+		dw.b.WriteString(dw.doc.newline)
+	}
+	dw.b.WriteString(s)
+}
+
+// write writes the raw text of a node if the node was not changed since it
+// was parsed or its canonical markup otherwise
+func (dw *documentWriter) write(rn *rawNode, canon string) {
+	if rn == nil {
+		dw.writeRaw(strings.ReplaceAll(canon, "\n", dw.doc.newline) + dw.doc.newline)
+		return
+	}
+	dw.writeRaw(rn.leading)
+	if rn.canon == canon {
+		dw.writeRaw(rn.indent + rn.text)
+		return
+	}
+	dw.writeRaw(rn.indent + strings.ReplaceAll(canon, "\n", dw.doc.newline) + rn.eol)
+}
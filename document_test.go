@@ -0,0 +1,94 @@
+package serifu
+
+import (
+	"strings"
+	"testing"
+)
+
+const documentInput = `
+!   script note
+#   PAGE 1
+  ! page note
+- 1.1
+
+   Shota/Sharp :   A _death match?!?_
+
+
+*   gasp  (haa)
+!note without space
+Sign:/=
+ Menu:
+   - Beer: 200 Yen
+=/
+
+## PAGE 2
+-2.1
+Palawan: Hi   `
+
+func TestParseDocument_roundTrip(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{"empty", ""},
+		{"blank lines only", "\n\n  \n"},
+		{"mixed spacing", documentInput},
+		{"windows line endings", strings.ReplaceAll(documentInput, "\n", "\r\n")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := ParseDocument(strings.NewReader(tt.input), ParserOptions{})
+			if err != nil {
+				t.Fatalf("ParseDocument() error = %v", err)
+			}
+			if got := doc.String(); got != tt.input {
+				t.Errorf("Document.String() = %q, want %q", got, tt.input)
+			}
+		})
+	}
+}
+
+func TestParseDocument_edit(t *testing.T) {
+	doc, err := ParseDocument(strings.NewReader(documentInput), ParserOptions{})
+	if err != nil {
+		t.Fatalf("ParseDocument() error = %v", err)
+	}
+	panel := doc.Script.Pages[0].Panels[0]
+	panel.Items[0].(*TextLine).Content = "A death match!"
+	doc.Script.Pages[1].Title = "PAGE 2-3"
+	doc.Script.Pages[1].Panels[0].Items = append(doc.Script.Pages[1].Panels[0].Items, &SideNote{Content: "added"})
+
+	want := strings.NewReplacer(
+		"   Shota/Sharp :   A _death match?!?_", "   Shota/Sharp: A death match!",
+		"## PAGE 2", "## PAGE 2-3",
+		"Palawan: Hi   ", "Palawan: Hi   \n! added\n",
+	).Replace(documentInput)
+	if got := doc.String(); got != want {
+		t.Errorf("Document.String() = %q, want %q", got, want)
+	}
+}
+
+func TestParseDocument_insert(t *testing.T) {
+	input := "# PAGE 1\n- 1.1\n  Shota :  Hi\n"
+	doc, err := ParseDocument(strings.NewReader(input), ParserOptions{})
+	if err != nil {
+		t.Fatalf("ParseDocument() error = %v", err)
+	}
+	panel := doc.Script.Pages[0].Panels[0]
+	panel.Items = append([]Item{&SoundEffect{Name: "gasp"}}, panel.Items...)
+	want := "# PAGE 1\n- 1.1\n* gasp\n  Shota :  Hi\n"
+	if got := doc.String(); got != want {
+		t.Errorf("Document.String() = %q, want %q", got, want)
+	}
+}
+
+func TestParseDocument_recover(t *testing.T) {
+	input := "# PAGE 1\nnot markup\n- 1.1\n"
+	doc, err := ParseDocument(strings.NewReader(input), ParserOptions{Recover: true})
+	if err == nil {
+		t.Errorf("ParseDocument() error = nil, want error")
+	}
+	if got := doc.String(); got != input {
+		t.Errorf("Document.String() = %q, want %q", got, input)
+	}
+}
@@ -0,0 +1,401 @@
+package serifu
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// DOCXColumn is a column of the script table of a DOCX document
+type DOCXColumn string
+
+const (
+	// PageColumn holds the page title on the first row of every page
+	PageColumn DOCXColumn = "page"
+	// PanelColumn holds the panel ID on the first row of every panel
+	PanelColumn DOCXColumn = "panel"
+	// SpeakerColumn holds the source, style and kind of text lines
+	SpeakerColumn DOCXColumn = "speaker"
+	// TextColumn holds the content of the items
+	TextColumn DOCXColumn = "text"
+	// NoteColumn holds side notes on the item of the row. It is only read.
+	NoteColumn DOCXColumn = "note"
+)
+
+// docxColumnTitles are the header cells of the columns
+var docxColumnTitles = map[DOCXColumn]string{
+	PageColumn:    "Page",
+	PanelColumn:   "Panel",
+	SpeakerColumn: "Speaker",
+	TextColumn:    "Text",
+}
+
+// docxColumnWidths are the widths of the columns in twentieths of a point
+var docxColumnWidths = map[DOCXColumn]int{
+	PageColumn:    1100,
+	PanelColumn:   1100,
+	SpeakerColumn: 2200,
+	TextColumn:    6300,
+}
+
+// DefaultDOCXColumns is the table layout used when DOCXOptions.Columns is
+// empty
+var DefaultDOCXColumns = []DOCXColumn{PanelColumn, SpeakerColumn, TextColumn}
+
+// DOCXOptions controls the output of EncodeDOCX
+type DOCXOptions struct {
+	// Columns are the table columns in order. The text column is required.
+	// Without a panel column every panel starts with a row holding its ID.
+	Columns []DOCXColumn
+	// Title is written above the first page. It defaults to the series and
+	// chapter of the front matter.
+	Title string
+}
+
+// EncodeDOCX writes s to w as a Word document. Every page starts on a new
+// sheet with a heading and a table of its panels; sound effects and side
+// notes get shaded rows and inline emphasis is kept as run formatting.
+func EncodeDOCX(w io.Writer, s *Script, opts DOCXOptions) error {
+	columns := opts.Columns
+	if len(columns) == 0 {
+		columns = DefaultDOCXColumns
+	}
+	hasText := false
+	for _, c := range columns {
+		if _, ok := docxColumnTitles[c]; !ok {
+			return fmt.Errorf("serifu: DOCX column %q can not be written", c)
+		}
+		hasText = hasText || c == TextColumn
+	}
+	if !hasText {
+		return fmt.Errorf("serifu: DOCX columns %v have no text column", columns)
+	}
+	e := &docxEncoder{columns: columns}
+	e.script(s, opts.Title)
+
+	zw := zip.NewWriter(w)
+	for _, f := range []struct{ name, data string }{
+		{"[Content_Types].xml", docxContentTypes},
+		{"_rels/.rels", docxRels},
+		{"word/_rels/document.xml.rels", docxDocumentRels},
+		{"word/styles.xml", docxStyles},
+		{"word/document.xml", docxDocumentStart + e.b.String() + docxDocumentEnd},
+	} {
+		fw, err := zw.CreateHeader(&zip.FileHeader{Name: f.name, Method: zip.Deflate})
+		if err != nil {
+			return err
+		}
+		if _, err := io.WriteString(fw, f.data); err != nil {
+			return err
+		}
+	}
+	return zw.Close()
+}
+
+// docxEncoder builds the body of word/document.xml
+type docxEncoder struct {
+	columns []DOCXColumn
+	b       strings.Builder
+	pages   int    // pages written so far
+	title   string // page title cell of the next row
+}
+
+func (e *docxEncoder) script(s *Script, title string) {
+	if title == "" && s.Meta != nil {
+		title = strings.TrimSpace(s.Meta.Series + " " + s.Meta.Chapter)
+	}
+	if title != "" {
+		e.paragraph("Title", "", docxRun(title, false, false))
+	}
+	if s.Meta != nil {
+		for _, f := range s.Meta.Fields() {
+			e.paragraph("", "", docxRun(f[0]+": ", true, false)+docxRun(f[1], false, false))
+		}
+	}
+	for _, n := range s.Notes {
+		e.paragraph("SerifuNote", "", docxRun(n.Content, false, false))
+	}
+	for _, p := range s.Pages {
+		e.page(p)
+	}
+	// a table can not end the document
+	e.paragraph("", "", "")
+}
+
+// paragraph writes a paragraph with the style and extra properties
+func (e *docxEncoder) paragraph(style, props, runs string) {
+	e.b.WriteString(docxParagraph(style, props, runs))
+}
+
+func (e *docxEncoder) page(p *Page) {
+	props := ""
+	if e.pages > 0 {
+		props = "<w:pageBreakBefore/>"
+	}
+	e.pages++
+	runs := docxRun(p.Title, false, false)
+	if p.IsSpread {
+		runs += docxRun(" ", false, false) + docxRun(docxSpreadLabel, true, false)
+	}
+	e.paragraph("Heading1", props, runs)
+	for _, n := range p.Notes {
+		e.paragraph("SerifuNote", "", docxRun(n.Content, false, false))
+	}
+	if len(p.Panels) == 0 {
+		return
+	}
+	e.b.WriteString(`<w:tbl><w:tblPr><w:tblStyle w:val="SerifuTable"/><w:tblW w:w="0" w:type="auto"/><w:tblLayout w:type="fixed"/></w:tblPr><w:tblGrid>`)
+	for _, c := range e.columns {
+		fmt.Fprintf(&e.b, `<w:gridCol w:w="%d"/>`, docxColumnWidths[c])
+	}
+	e.b.WriteString(`</w:tblGrid>`)
+	header := make(map[DOCXColumn]string)
+	for _, c := range e.columns {
+		header[c] = docxParagraph("", "", docxRun(docxColumnTitles[c], true, false))
+	}
+	e.row(`<w:trPr><w:tblHeader/></w:trPr>`, "D9D9D9", header)
+	e.title = docxParagraph("", "", docxRun(p.Title, true, false))
+	for _, pn := range p.Panels {
+		e.panel(pn)
+	}
+	e.b.WriteString(`</w:tbl>`)
+}
+
+func (e *docxEncoder) hasColumn(c DOCXColumn) bool {
+	for _, col := range e.columns {
+		if col == c {
+			return true
+		}
+	}
+	return false
+}
+
+func (e *docxEncoder) panel(pn *Panel) {
+	id := docxParagraph("SerifuPanel", "", docxRun(pn.ID, true, false))
+	if !e.hasColumn(PanelColumn) {
+		e.spanRow("F2F2F2", id)
+		id = ""
+	} else if len(pn.Items) == 0 {
+		e.row("", "", map[DOCXColumn]string{PanelColumn: id})
+	}
+	for _, item := range pn.Items {
+		var speaker, style, runs, fill string
+		switch i := item.(type) {
+		case *TextLine:
+			speaker = docxSpeaker(i)
+			if i.IsPreFormatted {
+				style = "SerifuPre"
+				runs = docxRun(strings.TrimSuffix(i.Content, "\n"), false, false)
+			} else {
+				spans := i.Spans
+				if spans == nil {
+					spans = ParseInline(i.Content)
+				}
+				runs = docxSpans(spans, false, false)
+			}
+		case *SoundEffect:
+			speaker, style, fill = docxSFXLabel, "SerifuSFX", "E8F0FE"
+			runs = docxRun(formatSoundEffectText(i), false, false)
+		case *SideNote:
+			speaker, style, fill = docxNoteLabel, "SerifuNote", "FFF8E1"
+			runs = docxRun(i.Content, false, false)
+		}
+		cells := map[DOCXColumn]string{PanelColumn: id}
+		id = ""
+		if e.hasColumn(SpeakerColumn) {
+			cells[SpeakerColumn] = docxParagraph("SerifuSpeaker", "", docxRun(speaker, false, false))
+		} else if speaker != "" {
+			// keep the speaker in front of the text
+			runs = docxRun(speaker+": ", true, false) + runs
+		}
+		cells[TextColumn] = docxParagraph(style, "", runs)
+		e.row("", fill, cells)
+	}
+}
+
+// row writes a table row with the cells of the columns. Missing cells are
+// left empty.
+func (e *docxEncoder) row(props, fill string, cells map[DOCXColumn]string) {
+	if e.title != "" && props == "" {
+		cells[PageColumn] = e.title
+		e.title = ""
+	}
+	e.b.WriteString("<w:tr>" + props)
+	for _, c := range e.columns {
+		content := cells[c]
+		if content == "" {
+			content = "<w:p/>"
+		}
+		e.b.WriteString(docxTableCell(docxColumnWidths[c], 1, fill, content))
+	}
+	e.b.WriteString("</w:tr>")
+}
+
+// spanRow writes a row with a single cell spanning every column
+func (e *docxEncoder) spanRow(fill, content string) {
+	width := 0
+	for _, c := range e.columns {
+		width += docxColumnWidths[c]
+	}
+	e.b.WriteString("<w:tr>" + docxTableCell(width, len(e.columns), fill, content) + "</w:tr>")
+}
+
+const (
+	docxSpreadLabel = "[SPREAD]"
+	docxSFXLabel    = "SFX"
+	docxNoteLabel   = "Note"
+	docxConnected   = "↳ "
+)
+
+// docxSpeaker returns the speaker cell of t: the source followed by the
+// style and kind in parentheses
+func docxSpeaker(t *TextLine) string {
+	var notes []string
+	if t.Style != "" {
+		notes = append(notes, t.Style)
+	}
+	if t.LineKind != DialogueLine {
+		notes = append(notes, string(t.LineKind))
+	}
+	s := t.Source
+	if len(notes) > 0 {
+		s = strings.TrimSpace(s + " (" + strings.Join(notes, ", ") + ")")
+	}
+	if t.Connected {
+		s = docxConnected + s
+	}
+	return s
+}
+
+func docxTableCell(width, span int, fill, content string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, `<w:tc><w:tcPr><w:tcW w:w="%d" w:type="dxa"/>`, width)
+	if span > 1 {
+		fmt.Fprintf(&b, `<w:gridSpan w:val="%d"/>`, span)
+	}
+	if fill != "" {
+		fmt.Fprintf(&b, `<w:shd w:val="clear" w:color="auto" w:fill="%s"/>`, fill)
+	}
+	b.WriteString("</w:tcPr>" + content + "</w:tc>")
+	return b.String()
+}
+
+func docxParagraph(style, props, runs string) string {
+	var b strings.Builder
+	b.WriteString("<w:p>")
+	if style != "" || props != "" {
+		b.WriteString("<w:pPr>")
+		if style != "" {
+			b.WriteString(`<w:pStyle w:val="` + style + `"/>`)
+		}
+		b.WriteString(props + "</w:pPr>")
+	}
+	b.WriteString(runs + "</w:p>")
+	return b.String()
+}
+
+// docxRun returns a run of text. Line breaks and tabs become break and tab
+// elements.
+func docxRun(text string, bold, italic bool) string {
+	if text == "" {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteString("<w:r>")
+	if bold || italic {
+		b.WriteString("<w:rPr>")
+		if bold {
+			b.WriteString("<w:b/>")
+		}
+		if italic {
+			b.WriteString("<w:i/>")
+		}
+		b.WriteString("</w:rPr>")
+	}
+	for i, l := range strings.Split(text, "\n") {
+		if i > 0 {
+			b.WriteString("<w:br/>")
+		}
+		for j, part := range strings.Split(l, "\t") {
+			if j > 0 {
+				b.WriteString("<w:tab/>")
+			}
+			if part != "" {
+				b.WriteString(`<w:t xml:space="preserve">` + docxEscape(part) + "</w:t>")
+			}
+		}
+	}
+	b.WriteString("</w:r>")
+	return b.String()
+}
+
+// docxSpans returns the runs of spans with emphasis as run formatting
+func docxSpans(spans []*Span, bold, italic bool) string {
+	var b strings.Builder
+	for _, s := range spans {
+		switch s.Kind {
+		case ItalicSpan:
+			b.WriteString(docxSpans(s.Children, bold, true))
+		case BoldSpan:
+			b.WriteString(docxSpans(s.Children, true, italic))
+		case BoldItalicSpan:
+			b.WriteString(docxSpans(s.Children, true, true))
+		case RubySpan:
+			b.WriteString(`<w:r><w:ruby><w:rubyPr><w:rubyAlign w:val="center"/><w:hps w:val="10"/>` +
+				`<w:hpsRaise w:val="18"/><w:hpsBaseText w:val="20"/><w:lid w:val="ja-JP"/></w:rubyPr>` +
+				"<w:rt>" + docxRun(s.Ruby, false, false) + "</w:rt>" +
+				"<w:rubyBase>" + docxRun(s.Text, bold, italic) + "</w:rubyBase></w:ruby></w:r>")
+		default:
+			b.WriteString(docxRun(s.Text, bold, italic))
+		}
+	}
+	return b.String()
+}
+
+func docxEscape(s string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
+
+const docxNamespace = "http://schemas.openxmlformats.org/wordprocessingml/2006/main"
+
+const docxContentTypes = xml.Header + `<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+	`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+	`<Default Extension="xml" ContentType="application/xml"/>` +
+	`<Override PartName="/word/document.xml" ContentType="application/vnd.openxmlformats-officedocument.wordprocessingml.document.main+xml"/>` +
+	`<Override PartName="/word/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.wordprocessingml.styles+xml"/>` +
+	`</Types>`
+
+const docxRels = xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+	`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="word/document.xml"/>` +
+	`</Relationships>`
+
+const docxDocumentRels = xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+	`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/>` +
+	`</Relationships>`
+
+const docxDocumentStart = xml.Header + `<w:document xmlns:w="` + docxNamespace + `"><w:body>`
+
+const docxDocumentEnd = `<w:sectPr><w:pgSz w:w="11906" w:h="16838"/>` +
+	`<w:pgMar w:top="1134" w:right="1134" w:bottom="1134" w:left="1134" w:header="567" w:footer="567" w:gutter="0"/>` +
+	`</w:sectPr></w:body></w:document>`
+
+const docxStyles = xml.Header + `<w:styles xmlns:w="` + docxNamespace + `">` +
+	`<w:docDefaults><w:rPrDefault><w:rPr><w:rFonts w:ascii="Calibri" w:hAnsi="Calibri" w:eastAsia="MS Mincho"/><w:sz w:val="22"/></w:rPr></w:rPrDefault></w:docDefaults>` +
+	`<w:style w:type="paragraph" w:default="1" w:styleId="Normal"><w:name w:val="Normal"/><w:pPr><w:spacing w:after="60"/></w:pPr></w:style>` +
+	`<w:style w:type="paragraph" w:styleId="Title"><w:name w:val="Title"/><w:basedOn w:val="Normal"/><w:pPr><w:spacing w:after="240"/></w:pPr><w:rPr><w:b/><w:sz w:val="40"/></w:rPr></w:style>` +
+	`<w:style w:type="paragraph" w:styleId="Heading1"><w:name w:val="heading 1"/><w:basedOn w:val="Normal"/><w:pPr><w:keepNext/><w:spacing w:before="240" w:after="120"/><w:outlineLvl w:val="0"/></w:pPr><w:rPr><w:b/><w:sz w:val="32"/></w:rPr></w:style>` +
+	`<w:style w:type="paragraph" w:customStyle="1" w:styleId="SerifuPanel"><w:name w:val="Serifu Panel"/><w:basedOn w:val="Normal"/></w:style>` +
+	`<w:style w:type="paragraph" w:customStyle="1" w:styleId="SerifuSpeaker"><w:name w:val="Serifu Speaker"/><w:basedOn w:val="Normal"/><w:rPr><w:b/></w:rPr></w:style>` +
+	`<w:style w:type="paragraph" w:customStyle="1" w:styleId="SerifuSFX"><w:name w:val="Serifu SFX"/><w:basedOn w:val="Normal"/><w:rPr><w:b/><w:caps/></w:rPr></w:style>` +
+	`<w:style w:type="paragraph" w:customStyle="1" w:styleId="SerifuNote"><w:name w:val="Serifu Note"/><w:basedOn w:val="Normal"/><w:rPr><w:i/><w:color w:val="7F6000"/></w:rPr></w:style>` +
+	`<w:style w:type="paragraph" w:customStyle="1" w:styleId="SerifuPre"><w:name w:val="Serifu Pre-formatted"/><w:basedOn w:val="Normal"/><w:rPr><w:rFonts w:ascii="Courier New" w:hAnsi="Courier New"/></w:rPr></w:style>` +
+	`<w:style w:type="table" w:customStyle="1" w:styleId="SerifuTable"><w:name w:val="Serifu Table"/><w:tblPr><w:tblBorders>` +
+	`<w:top w:val="single" w:sz="4" w:space="0" w:color="808080"/><w:left w:val="single" w:sz="4" w:space="0" w:color="808080"/>` +
+	`<w:bottom w:val="single" w:sz="4" w:space="0" w:color="808080"/><w:right w:val="single" w:sz="4" w:space="0" w:color="808080"/>` +
+	`<w:insideH w:val="single" w:sz="4" w:space="0" w:color="808080"/><w:insideV w:val="single" w:sz="4" w:space="0" w:color="808080"/>` +
+	`</w:tblBorders><w:tblCellMar><w:left w:w="80" w:type="dxa"/><w:right w:w="80" w:type="dxa"/></w:tblCellMar></w:tblPr></w:style>` +
+	`</w:styles>`
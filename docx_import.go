@@ -0,0 +1,788 @@
+package serifu
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// DOCXImportOptions controls DecodeDOCX
+type DOCXImportOptions struct {
+	// Columns are the roles of the table columns in order, an empty role
+	// ignores the column. When Columns is empty the roles are detected from
+	// the header row of every table, or from the number of columns of tables
+	// without one: text, speaker and text, panel, speaker and text, and page,
+	// panel, speaker, text and note for more columns.
+	Columns []DOCXColumn
+	// Headers maps additional header cell texts to column roles. Header
+	// texts are compared case insensitively.
+	Headers map[string]DOCXColumn
+}
+
+// docxHeaders are the header cell texts recognized for the column roles
+var docxHeaders = map[string]DOCXColumn{
+	"page":        PageColumn,
+	"pg":          PageColumn,
+	"page no":     PageColumn,
+	"page number": PageColumn,
+	"ページ":         PageColumn,
+	"panel":       PanelColumn,
+	"frame":       PanelColumn,
+	"koma":        PanelColumn,
+	"panel no":    PanelColumn,
+	"コマ":          PanelColumn,
+	"speaker":     SpeakerColumn,
+	"character":   SpeakerColumn,
+	"name":        SpeakerColumn,
+	"who":         SpeakerColumn,
+	"source":      SpeakerColumn,
+	"キャラ":         SpeakerColumn,
+	"text":        TextColumn,
+	"line":        TextColumn,
+	"lines":       TextColumn,
+	"dialogue":    TextColumn,
+	"dialog":      TextColumn,
+	"translation": TextColumn,
+	"english":     TextColumn,
+	"content":     TextColumn,
+	"セリフ":         TextColumn,
+	"台詞":          TextColumn,
+	"note":        NoteColumn,
+	"notes":       NoteColumn,
+	"tl note":     NoteColumn,
+	"tl notes":    NoteColumn,
+	"comment":     NoteColumn,
+	"comments":    NoteColumn,
+}
+
+// docxColumnsByCount are the roles of tables without a header row
+var docxColumnsByCount = [][]DOCXColumn{
+	{TextColumn},
+	{SpeakerColumn, TextColumn},
+	{PanelColumn, SpeakerColumn, TextColumn},
+	{PageColumn, PanelColumn, SpeakerColumn, TextColumn},
+	{PageColumn, PanelColumn, SpeakerColumn, TextColumn, NoteColumn},
+}
+
+// speaker cell texts of sound effect and side note rows
+var (
+	docxSFXSpeakers  = []string{"sfx", "fx", "sound", "sound effect"}
+	docxNoteSpeakers = []string{"note", "tl note", "t/n", "tn", "translator note", "comment"}
+)
+
+// docxPageTitle matches paragraphs and full width rows starting a page
+var docxPageTitle = regexp.MustCompile(`(?i)^pages?\s*\d`)
+
+// DOCXRowError is a table row DecodeDOCX could not classify
+type DOCXRowError struct {
+	// Table and Row are the 1-based positions of the row in the document
+	Table, Row int
+	// Text is the text of the row cells separated by tabs
+	Text   string
+	Reason string
+}
+
+func (e *DOCXRowError) Error() string {
+	return fmt.Sprintf("table %d, row %d: %s: %q", e.Table, e.Row, e.Reason, e.Text)
+}
+
+// DOCXRowErrors are the rows skipped by DecodeDOCX
+type DOCXRowErrors []*DOCXRowError
+
+func (l DOCXRowErrors) Error() string {
+	msgs := make([]string, len(l))
+	for i, e := range l {
+		msgs[i] = e.Error()
+	}
+	return strings.Join(msgs, "\n")
+}
+
+// DecodeDOCX reads a script from a Word document holding the script as
+// tables, one row per item. Page headings, or a page column, start pages and
+// the panel column, or rows spanning the whole table, start panels. Rows
+// with SFX or Note as the speaker are sound effects and side notes, the
+// others text lines; bold and italic runs become inline markup.
+//
+// Rows which can not be classified are skipped and returned as
+// DOCXRowErrors together with the rest of the script.
+func DecodeDOCX(r io.Reader, opts DOCXImportOptions) (*Script, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("serifu: reading DOCX: %w", err)
+	}
+	var body []docxBlock
+	found := false
+	for _, f := range zr.File {
+		if f.Name != "word/document.xml" {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return nil, err
+		}
+		body, err = readDOCXBody(rc)
+		rc.Close()
+		if err != nil {
+			return nil, fmt.Errorf("serifu: reading DOCX: %w", err)
+		}
+		found = true
+	}
+	if !found {
+		return nil, fmt.Errorf("serifu: reading DOCX: no word/document.xml")
+	}
+	d := &docxDecoder{opts: opts, script: &Script{Pages: make([]*Page, 0)}}
+	for _, b := range body {
+		if b.para != nil {
+			d.paragraph(b.para)
+		} else {
+			d.table(b.table)
+		}
+	}
+	if len(d.errors) > 0 {
+		return d.script, d.errors
+	}
+	return d.script, nil
+}
+
+// docxDecoder builds a script from the blocks of a document
+type docxDecoder struct {
+	opts   DOCXImportOptions
+	script *Script
+	page   *Page
+	panel  *Panel
+	tables int
+	errors DOCXRowErrors
+}
+
+// docxPageHeading returns the title of a page heading and if it is a
+// spread. Bare page numbers are titled like "PAGE 3".
+func docxPageHeading(s string) (string, bool) {
+	s = strings.TrimSpace(s)
+	spread := strings.HasSuffix(s, docxSpreadLabel)
+	if spread {
+		s = strings.TrimSpace(strings.TrimSuffix(s, docxSpreadLabel))
+	}
+	if isDigits(s) {
+		s = "PAGE " + s
+	}
+	return s, spread
+}
+
+func (d *docxDecoder) newPage(heading string) {
+	p := &Page{}
+	p.Title, p.IsSpread = docxPageHeading(heading)
+	d.page = p
+	d.panel = nil
+	d.script.Pages = append(d.script.Pages, p)
+}
+
+func (d *docxDecoder) newPanel(id string) {
+	if d.page == nil {
+		d.newPage("")
+	}
+	d.panel = &Panel{ID: strings.TrimSpace(id)}
+	d.page.Panels = append(d.page.Panels, d.panel)
+}
+
+func (d *docxDecoder) paragraph(p *docxPara) {
+	text := strings.TrimSpace(p.text())
+	switch {
+	case text == "":
+	case isDOCXHeading(p.style) || docxPageTitle.MatchString(text):
+		d.newPage(text)
+	case p.style == "SerifuNote":
+		note := &SideNote{Type: SideNoteItemType, Content: text}
+		if d.page != nil {
+			d.page.Notes = append(d.page.Notes, note)
+		} else {
+			d.script.Notes = append(d.script.Notes, note)
+		}
+	case d.page == nil && len(p.runs) > 1 && p.runs[0].bold && strings.HasSuffix(strings.TrimSpace(p.runs[0].text), ":"):
+		// bold labels before the first page are metadata
+		if d.script.Meta == nil {
+			d.script.Meta = &Meta{}
+		}
+		key := strings.TrimSuffix(strings.TrimSpace(p.runs[0].text), ":")
+		d.script.Meta.Set(key, strings.TrimSpace(text[len(strings.TrimSpace(p.runs[0].text)):]))
+	}
+}
+
+// columns returns the roles of the columns of t and the index of its first
+// row with content
+func (d *docxDecoder) columns(t *docxTable) ([]DOCXColumn, int) {
+	if len(d.opts.Columns) > 0 {
+		return d.opts.Columns, 0
+	}
+	if len(t.rows) > 0 {
+		var columns []DOCXColumn
+		hasText := false
+		for _, c := range t.rows[0] {
+			name := strings.ToLower(strings.Trim(strings.TrimSpace(c.text()), ".:#"))
+			role, ok := DOCXColumn(""), false
+			for k, v := range d.opts.Headers {
+				if strings.ToLower(k) == name {
+					role, ok = v, true
+				}
+			}
+			if !ok {
+				role = docxHeaders[name]
+			}
+			hasText = hasText || role == TextColumn
+			for i := 0; i < c.span; i++ {
+				columns = append(columns, role)
+			}
+		}
+		if hasText {
+			return columns, 1
+		}
+	}
+	width := 0
+	for _, row := range t.rows {
+		w := 0
+		for _, c := range row {
+			w += c.span
+		}
+		if w > width {
+			width = w
+		}
+	}
+	if width == 0 {
+		return nil, 0
+	}
+	if width > len(docxColumnsByCount) {
+		return docxColumnsByCount[len(docxColumnsByCount)-1], 0
+	}
+	return docxColumnsByCount[width-1], 0
+}
+
+func (d *docxDecoder) hasColumn(columns []DOCXColumn, role DOCXColumn) bool {
+	for _, c := range columns {
+		if c == role {
+			return true
+		}
+	}
+	return false
+}
+
+func (d *docxDecoder) table(t *docxTable) {
+	d.tables++
+	columns, first := d.columns(t)
+	var header string
+	if first > 0 {
+		header = t.rowText(0)
+	}
+	for i := first; i < len(t.rows); i++ {
+		if header != "" && t.rowText(i) == header {
+			// header repeated further down
+			continue
+		}
+		if reason := d.row(columns, t.rows[i]); reason != "" {
+			d.errors = append(d.errors, &DOCXRowError{
+				Table:  d.tables,
+				Row:    i + 1,
+				Text:   t.rowText(i),
+				Reason: reason,
+			})
+		}
+	}
+}
+
+// row adds the content of a table row to the script. It returns why the
+// row could not be classified or an empty string.
+func (d *docxDecoder) row(columns []DOCXColumn, row []*docxCell) string {
+	if len(row) == 1 && (row[0].span > 1 && row[0].span >= len(columns) || row[0].hasStyle("SerifuPanel")) {
+		// a row spanning the table starts a page or a panel
+		text := strings.TrimSpace(row[0].text())
+		switch {
+		case text == "":
+		case docxPageTitle.MatchString(text):
+			d.newPage(text)
+		default:
+			d.newPanel(text)
+		}
+		return ""
+	}
+	cells := make(map[DOCXColumn]*docxCell)
+	pos := 0
+	for _, c := range row {
+		if pos >= len(columns) {
+			if strings.TrimSpace(c.text()) != "" {
+				return "more cells than columns"
+			}
+			continue
+		}
+		role := columns[pos]
+		for j := pos + 1; j < pos+c.span && j < len(columns); j++ {
+			if columns[j] != "" && columns[j] != role && strings.TrimSpace(c.text()) != "" {
+				return "merged cell spans several columns"
+			}
+		}
+		if role != "" {
+			cells[role] = c
+		}
+		pos += c.span
+	}
+	cellText := func(role DOCXColumn) string {
+		if c := cells[role]; c != nil {
+			return strings.TrimSpace(c.text())
+		}
+		return ""
+	}
+	if heading := cellText(PageColumn); heading != "" {
+		if title, _ := docxPageHeading(heading); d.page == nil || title != d.page.Title {
+			d.newPage(heading)
+		}
+	}
+	if id := cellText(PanelColumn); id != "" {
+		if d.panel == nil || id != d.panel.ID {
+			d.newPanel(id)
+		}
+	}
+	speaker := cellText(SpeakerColumn)
+	text := cells[TextColumn]
+	if text != nil && !d.hasColumn(columns, SpeakerColumn) {
+		speaker = text.cutSpeaker()
+	}
+	note := cellText(NoteColumn)
+	if speaker == "" && (text == nil || strings.TrimSpace(text.text()) == "") && note == "" {
+		return ""
+	}
+	if d.panel == nil {
+		if d.hasColumn(columns, PanelColumn) {
+			return "item before the first panel"
+		}
+		if d.page == nil && d.hasColumn(columns, PageColumn) {
+			return "item before the first page"
+		}
+		d.newPanel("")
+	}
+	if speaker != "" || (text != nil && strings.TrimSpace(text.text()) != "") {
+		item, reason := docxItem(speaker, text)
+		if item == nil {
+			return reason
+		}
+		d.panel.Items = append(d.panel.Items, item)
+	}
+	if note != "" {
+		d.panel.Items = append(d.panel.Items, &SideNote{Type: SideNoteItemType, Content: note})
+	}
+	return ""
+}
+
+// docxItem returns the item of a row with the speaker and the text cell
+func docxItem(speaker string, text *docxCell) (Item, string) {
+	plain := ""
+	if text != nil {
+		plain = strings.TrimSpace(text.text())
+	}
+	switch lower := strings.ToLower(speaker); {
+	case containsString(docxSFXSpeakers, lower):
+		if plain == "" {
+			return nil, "sound effect without text"
+		}
+		return parseSoundEffect(plain), ""
+	case containsString(docxNoteSpeakers, lower):
+		return &SideNote{Type: SideNoteItemType, Content: plain}, ""
+	}
+	t := &TextLine{Type: TextLineItemType}
+	t.Source, t.Style, t.LineKind, t.Connected = docxParseSpeaker(speaker)
+	if text != nil {
+		t.IsPreFormatted = text.hasStyle("SerifuPre")
+		if t.IsPreFormatted {
+			t.Content = text.text()
+			// the exporter drops the final newline of multi-line blocks
+			if strings.Contains(t.Content, "\n") && !strings.HasSuffix(t.Content, "\n") {
+				t.Content += "\n"
+			}
+		} else {
+			t.Content = text.markup()
+		}
+	}
+	return t, ""
+}
+
+// docxParseSpeaker splits a speaker cell written by EncodeDOCX:
+// "↳ Source (Style, kind)"
+func docxParseSpeaker(s string) (source, style string, kind LineKind, connected bool) {
+	s = strings.TrimSpace(s)
+	for _, prefix := range []string{strings.TrimSpace(docxConnected), connectedPrefix} {
+		if strings.HasPrefix(s, prefix) {
+			connected = true
+			s = strings.TrimSpace(s[len(prefix):])
+		}
+	}
+	if i := readingGroupIndex(s); i > -1 {
+		var styles []string
+		known := true
+		for _, part := range strings.Split(s[i+1:len(s)-1], ",") {
+			part = strings.TrimSpace(part)
+			if _, k := cutLineKind("(" + part + ")"); k != DialogueLine || strings.EqualFold(part, DialogueLine.String()) {
+				kind = k
+				continue
+			}
+			if part == "" || strings.ContainsAny(part, "()") {
+				known = false
+			}
+			styles = append(styles, part)
+		}
+		if known && len(styles) <= 1 {
+			s = strings.TrimSpace(s[:i])
+			if len(styles) == 1 {
+				style = styles[0]
+			}
+		} else {
+			kind = DialogueLine
+		}
+	}
+	return s, style, kind, connected
+}
+
+func isDOCXHeading(style string) bool {
+	style = strings.ToLower(strings.ReplaceAll(style, " ", ""))
+	return strings.HasPrefix(style, "heading") && len(style) > len("heading")
+}
+
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if !unicode.IsDigit(r) {
+			return false
+		}
+	}
+	return true
+}
+
+func containsString(list []string, s string) bool {
+	for _, l := range list {
+		if l == s {
+			return true
+		}
+	}
+	return false
+}
+
+// docxRunText is a run of text with its formatting. Ruby runs carry the
+// annotation of the text.
+type docxRunText struct {
+	text         string
+	bold, italic bool
+	ruby         string
+}
+
+// docxPara is a paragraph of a document
+type docxPara struct {
+	style string
+	runs  []docxRunText
+}
+
+// text returns the text of the paragraph without formatting
+func (p *docxPara) text() string {
+	var b strings.Builder
+	for _, r := range p.runs {
+		b.WriteString(r.text)
+	}
+	return b.String()
+}
+
+// markup returns the text of the paragraph with bold, italic and ruby runs
+// as inline markup
+func (p *docxPara) markup() string {
+	var b strings.Builder
+	for i := 0; i < len(p.runs); {
+		r := p.runs[i]
+		if r.ruby != "" {
+			b.WriteString(docxEmphasis(string(rubyStart)+r.text+string(rubySeparator)+r.ruby+string(rubyEnd), r.bold, r.italic))
+			i++
+			continue
+		}
+		// merge the following runs with the same formatting
+		var text strings.Builder
+		for ; i < len(p.runs) && p.runs[i].ruby == "" && p.runs[i].bold == r.bold && p.runs[i].italic == r.italic; i++ {
+			text.WriteString(p.runs[i].text)
+		}
+		s := escapeInline(strings.ReplaceAll(text.String(), "\n", " "))
+		trimmed := strings.TrimSpace(s)
+		if trimmed == "" || !(r.bold || r.italic) {
+			b.WriteString(s)
+			continue
+		}
+		// keep the spaces outside of the markers
+		start := strings.Index(s, trimmed)
+		b.WriteString(s[:start] + docxEmphasis(trimmed, r.bold, r.italic) + s[start+len(trimmed):])
+	}
+	return b.String()
+}
+
+func docxEmphasis(s string, bold, italic bool) string {
+	switch {
+	case bold && italic:
+		return "***" + s + "***"
+	case bold:
+		return "**" + s + "**"
+	case italic:
+		return "_" + s + "_"
+	}
+	return s
+}
+
+// escapeInline escapes the characters of s which could be read as inline
+// markup. Text parsed as itself is returned unchanged.
+func escapeInline(s string) string {
+	if spans := ParseInline(s); len(spans) == 0 || len(spans) == 1 && spans[0].Kind == TextSpan {
+		return s
+	}
+	var b strings.Builder
+	for _, r := range s {
+		if r == '\\' || r == '*' || r == '_' || r == rubyStart {
+			b.WriteByte('\\')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// docxCell is a table cell spanning span grid columns
+type docxCell struct {
+	span  int
+	paras []*docxPara
+}
+
+func (c *docxCell) text() string {
+	texts := make([]string, len(c.paras))
+	for i, p := range c.paras {
+		texts[i] = p.text()
+	}
+	return strings.Join(texts, "\n")
+}
+
+func (c *docxCell) markup() string {
+	var texts []string
+	for _, p := range c.paras {
+		if m := strings.TrimSpace(p.markup()); m != "" {
+			texts = append(texts, m)
+		}
+	}
+	return strings.Join(texts, " ")
+}
+
+func (c *docxCell) hasStyle(style string) bool {
+	for _, p := range c.paras {
+		if p.style == style {
+			return true
+		}
+	}
+	return false
+}
+
+// cutSpeaker removes a leading bold "speaker:" run, as written by
+// EncodeDOCX without a speaker column, and returns the speaker
+func (c *docxCell) cutSpeaker() string {
+	if len(c.paras) == 0 || len(c.paras[0].runs) == 0 {
+		return ""
+	}
+	first := c.paras[0].runs[0]
+	label := strings.TrimSpace(first.text)
+	if !first.bold || !strings.HasSuffix(label, ":") {
+		return ""
+	}
+	c.paras[0].runs = c.paras[0].runs[1:]
+	return strings.TrimSpace(strings.TrimSuffix(label, ":"))
+}
+
+// docxTable is a table of a document
+type docxTable struct {
+	rows [][]*docxCell
+}
+
+func (t *docxTable) rowText(i int) string {
+	texts := make([]string, len(t.rows[i]))
+	for j, c := range t.rows[i] {
+		texts[j] = strings.TrimSpace(c.text())
+	}
+	return strings.Join(texts, "\t")
+}
+
+// docxBlock is a paragraph or a table of the document body
+type docxBlock struct {
+	para  *docxPara
+	table *docxTable
+}
+
+// readDOCXBody reads the paragraphs and tables of word/document.xml.
+// Nested tables are flattened into the cell holding them.
+func readDOCXBody(r io.Reader) ([]docxBlock, error) {
+	dec := xml.NewDecoder(r)
+	var (
+		blocks []docxBlock
+		depth  int // table nesting
+		table  *docxTable
+		row    []*docxCell
+		cell   *docxCell
+		para   *docxPara
+		run    docxRunText
+		inRun  bool // tabs and breaks outside runs are paragraph properties
+		inText bool
+		inRT   bool
+		ruby   *docxRunText
+	)
+	addText := func(s string) {
+		if para == nil {
+			return
+		}
+		switch {
+		case ruby != nil && inRT:
+			ruby.ruby += s
+		case ruby != nil:
+			ruby.text += s
+			ruby.bold, ruby.italic = run.bold, run.italic
+		default:
+			r := run
+			r.text = s
+			para.runs = append(para.runs, r)
+		}
+	}
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			return blocks, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			if t.Name.Space != docxNamespace {
+				continue
+			}
+			switch t.Name.Local {
+			case "tbl":
+				depth++
+				if depth == 1 {
+					table = &docxTable{}
+				}
+			case "tr":
+				if depth == 1 {
+					row = nil
+				}
+			case "tc":
+				if depth == 1 {
+					cell = &docxCell{span: 1}
+				}
+			case "gridSpan":
+				if depth == 1 && cell != nil {
+					fmt.Sscan(docxAttr(t, "val"), &cell.span)
+					if cell.span < 1 {
+						cell.span = 1
+					}
+				}
+			case "p":
+				para = &docxPara{}
+			case "pStyle":
+				if para != nil {
+					para.style = docxAttr(t, "val")
+				}
+			case "r":
+				run = docxRunText{}
+				inRun = true
+			case "b":
+				run.bold = docxOn(t)
+			case "i":
+				run.italic = docxOn(t)
+			case "t":
+				inText = true
+			case "tab":
+				if inRun {
+					addText("\t")
+				}
+			case "br", "cr":
+				if inRun && docxAttr(t, "type") != "page" {
+					addText("\n")
+				}
+			case "ruby":
+				ruby = &docxRunText{}
+			case "rt":
+				inRT = true
+			}
+		case xml.EndElement:
+			if t.Name.Space != docxNamespace {
+				continue
+			}
+			switch t.Name.Local {
+			case "t":
+				inText = false
+			case "r":
+				inRun = false
+			case "rt":
+				inRT = false
+			case "ruby":
+				if ruby != nil && para != nil {
+					if ruby.ruby == "" {
+						ruby.bold, ruby.italic = run.bold, run.italic
+						para.runs = append(para.runs, docxRunText{text: ruby.text, bold: ruby.bold, italic: ruby.italic})
+					} else {
+						para.runs = append(para.runs, *ruby)
+					}
+				}
+				ruby = nil
+			case "p":
+				switch {
+				case para == nil:
+				case cell != nil:
+					cell.paras = append(cell.paras, para)
+				case depth == 0:
+					blocks = append(blocks, docxBlock{para: para})
+				}
+				para = nil
+			case "tc":
+				if depth == 1 && cell != nil {
+					row = append(row, cell)
+					cell = nil
+				}
+			case "tr":
+				if depth == 1 {
+					table.rows = append(table.rows, row)
+				}
+			case "tbl":
+				depth--
+				if depth == 0 {
+					blocks = append(blocks, docxBlock{table: table})
+					table = nil
+				}
+			}
+		case xml.CharData:
+			if inText {
+				addText(string(t))
+			}
+		}
+	}
+}
+
+// docxAttr returns the value of the WordprocessingML attribute name
+func docxAttr(e xml.StartElement, name string) string {
+	for _, a := range e.Attr {
+		if a.Name.Local == name && (a.Name.Space == docxNamespace || a.Name.Space == "") {
+			return a.Value
+		}
+	}
+	return ""
+}
+
+// docxOn reports if a toggle property like bold is switched on
+func docxOn(e xml.StartElement) bool {
+	switch docxAttr(e, "val") {
+	case "0", "false", "off":
+		return false
+	}
+	return true
+}
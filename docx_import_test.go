@@ -0,0 +1,199 @@
+package serifu
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const docxInput = `---
+series: Moriking
+chapter: 31
+Cover Artist: Someone
+---
+
+! French edition
+
+# PAGE 1
+! mirrored
+- 1.1
+Shota/Sharp (thought): A _death_ **match** & {死闘|shitou} *star*
+* BAM (バン | ban) [top]
+! check the font
+(caption): Later
+& Aki (whisper): psst
+- 1.2
+
+## PAGE 2-3
+- 2.1
+Sign:/=
+Menu:
+	Beer
+=/
+Tag:/=one line=/
+`
+
+func TestDecodeDOCX_roundTrip(t *testing.T) {
+	want, err := Parse(strings.NewReader(docxInput))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	for _, columns := range [][]DOCXColumn{
+		nil,
+		{PageColumn, PanelColumn, SpeakerColumn, TextColumn},
+		{SpeakerColumn, TextColumn},
+		{TextColumn},
+	} {
+		var b bytes.Buffer
+		if err := EncodeDOCX(&b, want, DOCXOptions{Columns: columns}); err != nil {
+			t.Fatalf("EncodeDOCX(%v) error = %v", columns, err)
+		}
+		got, err := DecodeDOCX(&b, DOCXImportOptions{})
+		if err != nil {
+			t.Fatalf("DecodeDOCX(%v) error = %v", columns, err)
+		}
+		if !reflect.DeepEqual(got, withoutRanges(want)) {
+			t.Errorf("DecodeDOCX(%v) =\n%v\nwant\n%v", columns, got, want)
+		}
+	}
+}
+
+// docxFile returns a DOCX package with the document body
+func docxFile(t *testing.T, body string) []byte {
+	t.Helper()
+	var b bytes.Buffer
+	zw := zip.NewWriter(&b)
+	fw, err := zw.Create("word/document.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fw.Write([]byte(docxDocumentStart + body + docxDocumentEnd))
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return b.Bytes()
+}
+
+// legacyRow returns a table row with a cell for every text
+func legacyRow(texts ...string) string {
+	var b strings.Builder
+	b.WriteString("<w:tr>")
+	for _, s := range texts {
+		b.WriteString("<w:tc>")
+		if strings.HasPrefix(s, "span:") {
+			b.WriteString(`<w:tcPr><w:gridSpan w:val="2"/></w:tcPr>`)
+			s = s[len("span:"):]
+		}
+		b.WriteString(docxParagraph("", "", s) + "</w:tc>")
+	}
+	b.WriteString("</w:tr>")
+	return b.String()
+}
+
+func TestDecodeDOCX_legacyTable(t *testing.T) {
+	run := func(s string) string { return docxRun(s, false, false) }
+	body := docxParagraph("", "", run("Translation of chapter 4")) +
+		"<w:tbl>" +
+		legacyRow(run("Pg."), run("Frame"), run("Character"), run("English"), run("TL Notes"), run("Checked")) +
+		legacyRow(run("1"), run("1"), run("Mika"), run("Hello ")+docxRun("there", false, true), "", run("yes")) +
+		legacyRow("", "", run("Mika"), run("How are you?"), run("formal"), "") +
+		legacyRow("", run("2"), run("SFX"), run("doki doki"), "", "") +
+		legacyRow("", "", run("T/N"), run("heart beating"), "", "") +
+		legacyRow("", "", run("SFX"), "", "", "") +
+		legacyRow("", "", "span:"+run("Ken"), "", "", "") +
+		legacyRow("", "", "", "", "", run("ignored column")) +
+		legacyRow(run("Pg."), run("Frame"), run("Character"), run("English"), run("TL Notes"), run("Checked")) +
+		legacyRow(run("2"), run("1"), "", run("Later that day")) +
+		"</w:tbl>"
+	got, err := DecodeDOCX(bytes.NewReader(docxFile(t, body)), DOCXImportOptions{
+		Headers: map[string]DOCXColumn{"Checked": ""},
+	})
+	var rows DOCXRowErrors
+	if !errors.As(err, &rows) {
+		t.Fatalf("DecodeDOCX() error = %v, want DOCXRowErrors", err)
+	}
+	var reasons []string
+	for _, r := range rows {
+		reasons = append(reasons, r.Error())
+	}
+	wantReasons := []string{
+		`table 1, row 6: sound effect without text: "\t\tSFX\t\t\t"`,
+		`table 1, row 7: merged cell spans several columns: "\t\tKen\t\t\t"`,
+	}
+	if !reflect.DeepEqual(reasons, wantReasons) {
+		t.Errorf("DecodeDOCX() errors = %q, want %q", reasons, wantReasons)
+	}
+	want := `# PAGE 1
+- 1
+Mika: Hello _there_
+Mika: How are you?
+! formal
+- 2
+* doki doki
+! heart beating
+
+# PAGE 2
+- 1
+: Later that day
+`
+	if got.String() != want {
+		t.Errorf("DecodeDOCX() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestDecodeDOCX_columns(t *testing.T) {
+	run := func(s string) string { return docxRun(s, false, false) }
+	body := "<w:tbl>" +
+		legacyRow(run("1.1"), run("Mika"), run("Hi")) +
+		legacyRow("", run("Ken"), run("Yo")) +
+		"</w:tbl>"
+	tests := []struct {
+		name    string
+		columns []DOCXColumn
+		want    string
+	}{
+		{"by count", nil, "#\n- 1.1\nMika: Hi\nKen: Yo\n"},
+		{"mapped", []DOCXColumn{"", SpeakerColumn, TextColumn}, "#\n-\nMika: Hi\nKen: Yo\n"},
+		{"text only", []DOCXColumn{"", "", TextColumn}, "#\n-\n: Hi\n: Yo\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := DecodeDOCX(bytes.NewReader(docxFile(t, body)), DOCXImportOptions{Columns: tt.columns})
+			if err != nil {
+				t.Fatalf("DecodeDOCX() error = %v", err)
+			}
+			if got.String() != tt.want {
+				t.Errorf("DecodeDOCX() = %q, want %q", got.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestDecodeDOCX_tabStops(t *testing.T) {
+	run := func(s string) string { return docxRun(s, false, false) }
+	tabs := `<w:tabs><w:tab w:val="left" w:pos="720"/><w:tab w:val="left" w:pos="1440"/></w:tabs>`
+	body := "<w:tbl>" +
+		legacyRow(run("Panel"), run("Speaker"), run("Text")) +
+		"<w:tr><w:tc>" + docxParagraph("", tabs, run("1")) + "</w:tc>" +
+		"<w:tc>" + docxParagraph("", tabs, run("Sign")) + "</w:tc><w:tc>" +
+		docxParagraph("SerifuPre", tabs, run("line one")) +
+		docxParagraph("SerifuPre", tabs, run("\tline two")) +
+		"</w:tc></w:tr></w:tbl>"
+	got, err := DecodeDOCX(bytes.NewReader(docxFile(t, body)), DOCXImportOptions{})
+	if err != nil {
+		t.Fatalf("DecodeDOCX() error = %v", err)
+	}
+	line := got.Pages[0].Panels[0].Items[0].(*TextLine)
+	if want := "line one\n\tline two\n"; line.Content != want {
+		t.Errorf("DecodeDOCX() content = %q, want %q", line.Content, want)
+	}
+}
+
+func TestDecodeDOCX_invalid(t *testing.T) {
+	if _, err := DecodeDOCX(strings.NewReader("not a zip"), DOCXImportOptions{}); err == nil {
+		t.Errorf("DecodeDOCX() error = nil, want error")
+	}
+}
@@ -0,0 +1,143 @@
+package serifu
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"io"
+	"strings"
+	"testing"
+)
+
+// docxPart returns the content of a part of a DOCX package
+func docxPart(t *testing.T, data []byte, name string) string {
+	t.Helper()
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("zip.NewReader() error = %v", err)
+	}
+	for _, f := range zr.File {
+		if f.Name != name {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatalf("Open(%s) error = %v", name, err)
+		}
+		defer rc.Close()
+		b, err := io.ReadAll(rc)
+		if err != nil {
+			t.Fatalf("ReadAll(%s) error = %v", name, err)
+		}
+		return string(b)
+	}
+	t.Fatalf("part %s not found", name)
+	return ""
+}
+
+func TestEncodeDOCX(t *testing.T) {
+	s, err := Parse(strings.NewReader(`# PAGE 1
+! mirrored
+- 1.1
+Shota/Sharp (thought): A _death_ **match** & {死闘|shitou}
+* BAM (ban)
+! check the font
+
+## PAGE 2-3
+- 2.1
+Sign:/=
+Menu
+=/
+`))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	tests := []struct {
+		name     string
+		opts     DOCXOptions
+		contains []string
+		missing  []string
+	}{
+		{
+			"default columns",
+			DOCXOptions{Title: "Moriking <31>"},
+			[]string{
+				`<w:t xml:space="preserve">Moriking &lt;31&gt;</w:t>`,
+				`<w:gridCol w:w="1100"/><w:gridCol w:w="2200"/><w:gridCol w:w="6300"/>`,
+				`<w:t xml:space="preserve">Panel</w:t>`,
+				`<w:t xml:space="preserve">Shota (Sharp, thought)</w:t>`,
+				`<w:r><w:rPr><w:i/></w:rPr><w:t xml:space="preserve">death</w:t></w:r>`,
+				`<w:r><w:rPr><w:b/></w:rPr><w:t xml:space="preserve">match</w:t></w:r>`,
+				`<w:t xml:space="preserve"> &amp; </w:t>`,
+				`<w:rt><w:r><w:t xml:space="preserve">shitou</w:t></w:r></w:rt><w:rubyBase><w:r><w:t xml:space="preserve">死闘</w:t></w:r></w:rubyBase>`,
+				`<w:shd w:val="clear" w:color="auto" w:fill="E8F0FE"/></w:tcPr><w:p><w:pPr><w:pStyle w:val="SerifuSFX"/></w:pPr><w:r><w:t xml:space="preserve">BAM (ban)</w:t>`,
+				`<w:pStyle w:val="SerifuNote"/></w:pPr><w:r><w:t xml:space="preserve">check the font</w:t>`,
+				`<w:pPr><w:pStyle w:val="Heading1"/><w:pageBreakBefore/></w:pPr><w:r><w:t xml:space="preserve">PAGE 2-3</w:t></w:r><w:r><w:t xml:space="preserve"> </w:t></w:r><w:r><w:rPr><w:b/></w:rPr><w:t xml:space="preserve">[SPREAD]</w:t>`,
+				`<w:pStyle w:val="SerifuPre"/></w:pPr><w:r><w:t xml:space="preserve">Menu</w:t></w:r>`,
+			},
+			[]string{"<w:gridSpan"},
+		},
+		{
+			"text only",
+			DOCXOptions{Columns: []DOCXColumn{TextColumn}},
+			[]string{
+				`<w:tcW w:w="6300" w:type="dxa"/><w:shd w:val="clear" w:color="auto" w:fill="F2F2F2"/>`,
+				`<w:r><w:rPr><w:b/></w:rPr><w:t xml:space="preserve">Shota (Sharp, thought): </w:t></w:r>`,
+				`<w:r><w:rPr><w:b/></w:rPr><w:t xml:space="preserve">SFX: </w:t></w:r>`,
+			},
+			[]string{"Speaker"},
+		},
+		{
+			"speaker and text",
+			DOCXOptions{Columns: []DOCXColumn{SpeakerColumn, TextColumn}},
+			[]string{`<w:gridSpan w:val="2"/>`},
+			nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var b bytes.Buffer
+			if err := EncodeDOCX(&b, s, tt.opts); err != nil {
+				t.Fatalf("EncodeDOCX() error = %v", err)
+			}
+			for _, name := range []string{"[Content_Types].xml", "_rels/.rels", "word/_rels/document.xml.rels", "word/styles.xml"} {
+				checkXML(t, name, docxPart(t, b.Bytes(), name))
+			}
+			doc := docxPart(t, b.Bytes(), "word/document.xml")
+			checkXML(t, "word/document.xml", doc)
+			for _, want := range tt.contains {
+				if !strings.Contains(doc, want) {
+					t.Errorf("document.xml does not contain %s", want)
+				}
+			}
+			for _, unwanted := range tt.missing {
+				if strings.Contains(doc, unwanted) {
+					t.Errorf("document.xml contains %s", unwanted)
+				}
+			}
+		})
+	}
+}
+
+// checkXML fails the test when s is not well formed XML
+func checkXML(t *testing.T, name, s string) {
+	t.Helper()
+	d := xml.NewDecoder(strings.NewReader(s))
+	for {
+		_, err := d.Token()
+		if err == io.EOF {
+			return
+		}
+		if err != nil {
+			t.Fatalf("%s is not well formed: %v", name, err)
+		}
+	}
+}
+
+func TestEncodeDOCX_invalidColumns(t *testing.T) {
+	for _, columns := range [][]DOCXColumn{{PanelColumn, SpeakerColumn}, {NoteColumn, TextColumn}} {
+		if err := EncodeDOCX(io.Discard, &Script{}, DOCXOptions{Columns: columns}); err == nil {
+			t.Errorf("EncodeDOCX(%v) error = nil, want error", columns)
+		}
+	}
+}
@@ -0,0 +1,112 @@
+package serifu
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Severity is the severity of a ParseError
+type Severity int
+
+const (
+	// SeverityError marks input which is not valid Serifu
+	SeverityError Severity = iota
+	// SeverityWarning marks input which is valid but probably wrong
+	SeverityWarning
+)
+
+func (s Severity) String() string {
+	switch s {
+	case SeverityError:
+		return "error"
+	case SeverityWarning:
+		return "warning"
+	}
+	return fmt.Sprintf("severity(%d)", int(s))
+}
+
+// ErrorKind is the kind of problem reported by a ParseError
+type ErrorKind string
+
+const (
+	// PanelOutsidePage is reported for a panel before the first page
+	PanelOutsidePage ErrorKind = "panel outside page"
+	// SoundOutsidePanel is reported for a sound effect outside of a panel
+	SoundOutsidePanel ErrorKind = "sound outside panel"
+	// TextLineOutsidePanel is reported for a text line outside of a panel
+	TextLineOutsidePanel ErrorKind = "text line outside panel"
+	// UnexpectedMarkup is reported for lines which can't be recognized
+	UnexpectedMarkup ErrorKind = "unexpected markup"
+	// InvalidMetadata is reported for front matter lines which are not
+	// key/value pairs
+	InvalidMetadata ErrorKind = "invalid metadata"
+	// UnterminatedBlock is reported for a pre-formatted block or front
+	// matter without end marker
+	UnterminatedBlock ErrorKind = "unterminated block"
+)
+
+// ParseError is a problem found in the input. Use errors.As to get it from
+// the error returned by Parse.
+type ParseError struct {
+	Pos      Position
+	Severity Severity
+	Kind     ErrorKind
+	Msg      string
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("line %d: %s", e.Pos.Line, e.Msg)
+}
+
+// Diagnostic is a ParseError collected by a recovering parse
+type Diagnostic = ParseError
+
+// ErrorList is the list of all problems found by a recovering parse
+type ErrorList []*ParseError
+
+func (l ErrorList) Error() string {
+	switch len(l) {
+	case 0:
+		return "no errors"
+	case 1:
+		return l[0].Error()
+	}
+	var b strings.Builder
+	for i, e := range l {
+		if i > 0 {
+			b.WriteByte('\n')
+		}
+		b.WriteString(e.Error())
+	}
+	return b.String()
+}
+
+// As finds the first error in the list matching target so errors.As can
+// reach the errors of a recovering parse
+func (l ErrorList) As(target interface{}) bool {
+	for _, e := range l {
+		if errors.As(e, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// Err returns nil for an empty list or the list itself
+func (l ErrorList) Err() error {
+	if len(l) == 0 {
+		return nil
+	}
+	return l
+}
+
+// HasErrors reports if the list contains problems with SeverityError
+func (l ErrorList) HasErrors() bool {
+	for _, e := range l {
+		if e.Severity == SeverityError {
+			return true
+		}
+	}
+	return false
+}
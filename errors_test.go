@@ -0,0 +1,136 @@
+package serifu
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParse_parseError(t *testing.T) {
+	_, err := Parse(strings.NewReader("# PAGE 1\n  test"))
+	var pe *ParseError
+	if !errors.As(err, &pe) {
+		t.Fatalf("Parse() error = %v, want *ParseError", err)
+	}
+	want := &ParseError{
+		Pos:      Position{Offset: 11, Line: 2, Column: 3},
+		Severity: SeverityError,
+		Kind:     UnexpectedMarkup,
+		Msg:      "unexpected markup: `  test`",
+	}
+	if !reflect.DeepEqual(pe, want) {
+		t.Errorf("Parse() error = %#v, want %#v", pe, want)
+	}
+}
+
+func TestParseWithOptions_recover(t *testing.T) {
+	input := `- 0.1
+# PAGE 1
+Aki: early
+- 1.1
+Shota: Hi
+test
+* gasp
+`
+	got, err := ParseWithOptions(strings.NewReader(input), ParserOptions{Recover: true})
+	var list ErrorList
+	if !errors.As(err, &list) {
+		t.Fatalf("ParseWithOptions() error = %v, want ErrorList", err)
+	}
+	var kinds []ErrorKind
+	var lines []int
+	for _, e := range list {
+		kinds = append(kinds, e.Kind)
+		lines = append(lines, e.Pos.Line)
+	}
+	wantKinds := []ErrorKind{PanelOutsidePage, TextLineOutsidePanel, UnexpectedMarkup}
+	if !reflect.DeepEqual(kinds, wantKinds) {
+		t.Errorf("kinds = %v, want %v", kinds, wantKinds)
+	}
+	if wantLines := []int{1, 3, 6}; !reflect.DeepEqual(lines, wantLines) {
+		t.Errorf("lines = %v, want %v", lines, wantLines)
+	}
+	if got == nil || len(got.Pages) != 1 || len(got.Pages[0].Panels[0].Items) != 2 {
+		t.Errorf("ParseWithOptions() = %v, want partial script", got)
+	}
+	var pe *ParseError
+	if !errors.As(err, &pe) || pe.Kind != PanelOutsidePage {
+		t.Errorf("errors.As(*ParseError) = %v, want first error", pe)
+	}
+}
+
+func TestErrorList_Err(t *testing.T) {
+	var l ErrorList
+	if l.Err() != nil {
+		t.Errorf("ErrorList.Err() = %v, want nil", l.Err())
+	}
+	l = append(l, &ParseError{Pos: Position{Line: 2}, Msg: "one"}, &ParseError{Pos: Position{Line: 4}, Msg: "two"})
+	if got, want := l.Err().Error(), "line 2: one\nline 4: two"; got != want {
+		t.Errorf("ErrorList.Error() = %q, want %q", got, want)
+	}
+}
+
+func TestErrorList_As(t *testing.T) {
+	l := ErrorList{{Pos: Position{Line: 2}, Kind: PanelOutsidePage}, {Pos: Position{Line: 4}}}
+	var pe *ParseError
+	if !errors.As(fmt.Errorf("parse: %w", l), &pe) || pe != l[0] {
+		t.Errorf("errors.As(*ParseError) = %v, want %v", pe, l[0])
+	}
+	var list ErrorList
+	if !errors.As(l, &list) || len(list) != 2 {
+		t.Errorf("errors.As(ErrorList) = %v, want the list", list)
+	}
+	var nf *os.PathError
+	if errors.As(l, &nf) {
+		t.Errorf("errors.As(*os.PathError) = %v, want false", nf)
+	}
+}
+
+const unterminatedInput = `# PAGE 1
+- 1.1
+Sign:/=
+Menu
+- 1.2
+Shota: Hi
+# PAGE 2
+`
+
+func TestParse_unterminatedBlock(t *testing.T) {
+	_, err := Parse(strings.NewReader(unterminatedInput))
+	var pe *ParseError
+	if !errors.As(err, &pe) {
+		t.Fatalf("Parse() error = %v, want *ParseError", err)
+	}
+	if pe.Kind != UnterminatedBlock || pe.Pos.Line != 3 {
+		t.Errorf("Parse() error = %v (%s), want unterminated block on line 3", pe, pe.Kind)
+	}
+}
+
+func TestParseWithOptions_recoverUnterminatedBlock(t *testing.T) {
+	got, err := ParseWithOptions(strings.NewReader(unterminatedInput), ParserOptions{Recover: true})
+	var list ErrorList
+	if !errors.As(err, &list) || len(list) != 1 || list[0].Kind != UnterminatedBlock {
+		t.Fatalf("ParseWithOptions() error = %v, want one unterminated block", err)
+	}
+	var panels []string
+	for _, p := range got.Pages {
+		for _, pn := range p.Panels {
+			panels = append(panels, pn.ID)
+		}
+	}
+	if want := []string{"1.1", "1.2"}; len(got.Pages) != 2 || !reflect.DeepEqual(panels, want) {
+		t.Errorf("panels = %v in %d pages, want %v in 2 pages", panels, len(got.Pages), want)
+	}
+	line := got.Pages[0].Panels[1].Items[0].(*TextLine)
+	if line.Content != "Hi" || line.Start.Line != 6 {
+		t.Errorf("text line = %v at %v, want Hi at line 6", line, line.Start)
+	}
+
+	doc, _ := ParseDocument(strings.NewReader(unterminatedInput), ParserOptions{Recover: true})
+	if got := doc.String(); got != unterminatedInput {
+		t.Errorf("Document.String() = %q, want %q", got, unterminatedInput)
+	}
+}
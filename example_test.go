@@ -3,6 +3,7 @@ package serifu_test
 import (
 	"encoding/json"
 	"fmt"
+	"io"
 	"strings"
 
 	"github.com/aquilax/serifu-go"
@@ -168,7 +169,7 @@ func ExampleParse() {
 	//             {
 	//               "type": "soundEffect",
 	//               "name": "gasp",
-	//               "transliteration": "ha"
+	//               "transliteration": "haa"
 	//             },
 	//             {
 	//               "type": "text",
@@ -367,7 +368,7 @@ func ExampleParse() {
 	//             {
 	//               "type": "soundEffect",
 	//               "name": "glare",
-	//               "transliteration": "jii"
+	//               "transliteration": "jiii"
 	//             },
 	//             {
 	//               "type": "text",
@@ -471,7 +472,7 @@ func ExampleParse() {
 	//               "source": "Contract Text",
 	//               "style": "",
 	//               "is_pre_formatted": true,
-	//               "content": " The undersigned* agrees to sell his soul** for a thousand berries.**"
+	//               "content": " The undersigned* agrees to sell his soul** for a thousand berries.***"
 	//             },
 	//             {
 	//               "type": "text",
@@ -497,87 +498,109 @@ func ExampleScript_String() {
 
 	// Output:
 	// # PAGE 1
-	//     - 1.1
-	//
-	//     - 1.2
-	//     Menelaus/Menelaus: Here in the mountains of Japan…
-	//     Menelaus/Menelaus: …There is a steel cage made for one purpose.
-	//
-	//     - 1.3
-	//
-	//     - 1.4
-	//     Menelaus/Menelaus:
-	//     Menelaus/Menelaus:
-	//     Title: Moriking
-	//     Chapter Title: Chapter 31: Giant Asian Hornet vs. Palawan Stag Beetle
-	//     Shoko/Shoko: ?!
-	//
-	//     - 1.5
-	//     * gasp (ha)
-	//     Shota/Shota: A _death match?!?_ The invitation said it was gonna be arm wrestling...!
-	//     Menelaus/Menelaus: It was changed at the last minute...
-	//     Menelaus/Menelaus: ...at the strong insistence of the seeded contestant.
-	//
-	//     - 1.6
-	//     Palawan/Palawan: I have no interest in such **pathetic games.**
+	// - 1.1
+	// - 1.2
+	// Menelaus/Announcing: Here in the mountains of Japan…
+	// Menelaus/Announcing: …There is a steel cage made for one purpose.
+	// - 1.3
+	// - 1.4
+	// Menelaus/Announcing:
+	// Menelaus/Announcing:
+	// Title: Moriking
+	// Chapter Title: Chapter 31: Giant Asian Hornet vs. Palawan Stag Beetle
+	// Shoko/Shadowed: ?!
+	// - 1.5
+	// * gasp (haa)
+	// Shota/Sharp: A _death match?!?_ The invitation said it was gonna be arm wrestling...!
+	// Menelaus/Announcing: It was changed at the last minute...
+	// Menelaus/Announcing: ...at the strong insistence of the seeded contestant.
+	// - 1.6
+	// Palawan/Serious: I have no interest in such **pathetic games.**
 	//
 	// ## PAGE 2
-	//     - 2.1
-	//     Palawan/Palawan: The only creatures with any right to live...
-	//     Palawan/Palawan: ...are those with the beauty of strength.
-	//     * ha ha ha
-	//
-	//     - 2.2
-	//     Shota/Shota: The Palawan...
-	//     Shota/Shota: ...Stag Beetle...
-	//     Shoko/Shoko: The what now?
-	//
-	//     - 2.3
-	//     Shota/Shota: A giant stag beetle that lives on the Palawan archipelago in the Philippines!!
-	//     Shota/Shota: With its overwhelming prowess in battle, it's said to be the strongest stag beetle on the planet!!
-	//     Shoko/Shoko: Okay, so it's another cool bug, got it.
+	// - 2.1
+	// Palawan/Serious: The only creatures with any right to live...
+	// Palawan/Serious: ...are those with the beauty of strength.
+	// * ha ha ha
+	// - 2.2
+	// Shota/Scared: The Palawan...
+	// Shota/Scared: ...Stag Beetle...
+	// Shoko/Bold: The what now?
+	// - 2.3
+	// Shota/Sharp: A giant stag beetle that lives on the Palawan archipelago in the Philippines!!
+	// Shota/Sharp: With its overwhelming prowess in battle, it's said to be the strongest stag beetle on the planet!!
+	// Shoko/Thought: Okay, so it's another cool bug, got it.
 	//
 	// # PAGE 3
-	//     - 3.1
-	//     Palawan/Palawan: You all disgust me.
-	//     ! he is not really serious
-	//
-	//     - 3.2
-	//     Palawan: It is we Insecters who are the rightful masters of all life.
-	//     Palawan: And only the most powerful among us...
-	//     Palawan: ...is fit to rule the planet.
-	//
-	//     - 3.3
-	//     Palawan: Filthy pests and minor species from irrelevant islands...
-	//     Palawan: My world has no need for such trash.
-	//
-	//     - 3.4
-	//     Palawan: Send out your champion...
-	//     * glare (jii)
-	//     Palawan: ...and I will end them.
-	//
-	//     - 3.5
-	//     Shota: What should we do? The only other battle to the death we did was with...
-	//     Shoko: Huh? Speaking of which, where's Oga?
-	//     Ko/Ko: Actually, I haven't seen him for a few days...!
-	//     Shoko: Yeah, he wasn't here for round two, either. Weird.
-	//
-	//     - 3.6
-	//     Oki: Ha ha ha, guess he got freaked out and split!
-	//     Oki: That's okay, I got this one!
-	//     Shoko/Shoko: Wait, didn't Oki only get his butt whacked?
-	//
-	//     - 3.7
-	//     Moriking: I will face him.
-	//     Shoko/Shoko: Mori--!
-	//     Meo/Meo: Hold up.
-
-	//     - 3.8
-	//     Contract Text:/= The undersigned* agrees to sell his soul** for a thousand berries.**=/
-	//     Sign:/=Menu:
+	// - 3.1
+	// Palawan/Serious: You all disgust me.
+	// ! he is not really serious
+	// - 3.2
+	// Palawan: It is we Insecters who are the rightful masters of all life.
+	// Palawan: And only the most powerful among us...
+	// Palawan: ...is fit to rule the planet.
+	// - 3.3
+	// Palawan: Filthy pests and minor species from irrelevant islands...
+	// Palawan: My world has no need for such trash.
+	// - 3.4
+	// Palawan: Send out your champion...
+	// * glare (jiii)
+	// Palawan: ...and I will end them.
+	// - 3.5
+	// Shota: What should we do? The only other battle to the death we did was with...
+	// Shoko: Huh? Speaking of which, where's Oga?
+	// Ko/Bold: Actually, I haven't seen him for a few days...!
+	// Shoko: Yeah, he wasn't here for round two, either. Weird.
+	// - 3.6
+	// Oki: Ha ha ha, guess he got freaked out and split!
+	// Oki: That's okay, I got this one!
+	// Shoko/Thought: Wait, didn't Oki only get his butt whacked?
+	// - 3.7
+	// Moriking: I will face him.
+	// Shoko/Bold: Mori--!
+	// Meo/Bold: Hold up.
+	// - 3.8
+	// Contract Text:/= The undersigned* agrees to sell his soul** for a thousand berries.***=/
+	// Sign:/=
+	// Menu:
 	// - Pizza: 50 Yen
 	// - Okonomiyaki: 100 Yen
 	// - Beer: 200 Yen
 	// =/
+}
+
+func ExampleParseDocument() {
+	input := "# PAGE 1\n-  1.1\n\nShota/Sharp :  Hello!\nShoko:   Hi.\n"
+	doc, err := serifu.ParseDocument(strings.NewReader(input), serifu.ParserOptions{})
+	if err != nil {
+		fmt.Println(err)
+	}
+	panel := doc.Script.Pages[0].Panels[0]
+	panel.Items[1].(*serifu.TextLine).Content = "Hey."
+	fmt.Print(doc)
+	// Output:
+	// # PAGE 1
+	// -  1.1
+	//
+	// Shota/Sharp :  Hello!
+	// Shoko: Hey.
+}
 
+func ExampleDecoder() {
+	d := serifu.NewDecoder(strings.NewReader(spec))
+	for {
+		page, err := d.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			fmt.Println(err)
+			break
+		}
+		fmt.Println(page.Title, len(page.Panels))
+	}
+	// Output:
+	// PAGE 1 6
+	// PAGE 2 3
+	// PAGE 3 8
 }
@@ -0,0 +1,215 @@
+package serifu
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"unicode"
+)
+
+// FormatOptions controls the layout produced by Format. The zero value
+// produces the canonical layout.
+type FormatOptions struct {
+	// Indent is written once before panel lines and twice before item lines
+	Indent string
+	// BlankLinesBetweenPanels is the number of empty lines written between
+	// the panels of a page
+	BlankLinesBetweenPanels int
+	// NoSpaceAfterColon removes the space between a text line heading and
+	// its content
+	NoSpaceAfterColon bool
+	// Dialect sets the markers to write, nil writes DefaultDialect
+	Dialect *Dialect
+}
+
+// Format writes s to w as Serifu markup. Parsing the output of Format
+// returns a script equal to s for every script returned by Parse.
+func Format(w io.Writer, s *Script, opts FormatOptions) error {
+	f := newFormatter(w, opts)
+	f.script(s)
+	return f.err
+}
+
+// FormatDocument writes the script of doc like Format. The comments and the
+// lines skipped in recovering mode are kept above the node they preceded.
+func FormatDocument(w io.Writer, doc *Document, opts FormatOptions) error {
+	f := newFormatter(w, opts)
+	f.doc = doc
+	f.script(doc.Script)
+	f.trivia(doc.trivia)
+	return f.err
+}
+
+// formatter writes nodes as markup remembering the first write error
+type formatter struct {
+	w    io.Writer
+	opts FormatOptions
+	d    *Dialect
+	doc  *Document // document to take the comments from, if any
+	err  error
+}
+
+func newFormatter(w io.Writer, opts FormatOptions) *formatter {
+	return &formatter{w: w, opts: opts, d: opts.Dialect.complete()}
+}
+
+func (f *formatter) line(indent, s string) {
+	if f.err != nil {
+		return
+	}
+	if s != "" {
+		s = indent + s
+	}
+	_, f.err = io.WriteString(f.w, s+"\n")
+}
+
+// node writes the markup s of node preceded by its comments
+func (f *formatter) node(indent string, node interface{}, s string) {
+	if f.doc != nil {
+		if rn := f.doc.nodes[node]; rn != nil {
+			f.trivia(rn.leading)
+		}
+	}
+	f.line(indent, s)
+}
+
+// trivia writes the non blank lines of raw input as they are
+func (f *formatter) trivia(raw string) {
+	raw = strings.Trim(raw, "\r\n")
+	if strings.TrimSpace(raw) == "" {
+		return
+	}
+	for _, l := range strings.Split(raw, "\n") {
+		f.line("", strings.TrimRightFunc(l, unicode.IsSpace))
+	}
+}
+
+func (f *formatter) script(s *Script) {
+	if s.Meta != nil {
+		f.node("", s.Meta, f.d.frontMatter(s.Meta))
+	}
+	if len(s.Notes) > 0 {
+		if s.Meta != nil {
+			f.line("", "")
+		}
+		for _, n := range s.Notes {
+			f.node("", n, f.d.item(n))
+		}
+	}
+	for i, p := range s.Pages {
+		if i > 0 || s.Meta != nil || len(s.Notes) > 0 {
+			f.line("", "")
+		}
+		f.page(p)
+	}
+}
+
+func (f *formatter) page(p *Page) {
+	f.node("", p, f.d.pageLine(p))
+	for _, n := range p.Notes {
+		f.node(f.opts.Indent, n, f.d.item(n))
+	}
+	for i, pn := range p.Panels {
+		if i > 0 {
+			for j := 0; j < f.opts.BlankLinesBetweenPanels; j++ {
+				f.line("", "")
+			}
+		}
+		f.panel(pn)
+	}
+}
+
+func (f *formatter) panel(pn *Panel) {
+	f.node(f.opts.Indent, pn, f.d.panelLine(pn))
+	for _, item := range pn.Items {
+		s := f.d.item(item)
+		if t, ok := item.(*TextLine); ok && f.opts.NoSpaceAfterColon {
+			s = f.d.textLine(t, "")
+		}
+		f.node(f.opts.Indent+f.opts.Indent, item, s)
+	}
+}
+
+// formatMarker joins a line prefix and its value
+func formatMarker(prefix, value string) string {
+	if value == "" {
+		return prefix
+	}
+	return prefix + " " + value
+}
+
+// pageLine returns the definition line of the page
+func (d *Dialect) pageLine(p *Page) string {
+	if p.IsSpread {
+		return formatMarker(d.PageSpreadPrefix, withAttrs(p.Title, p.Attrs))
+	}
+	return formatMarker(d.PagePrefix, withAttrs(p.Title, p.Attrs))
+}
+
+// panelLine returns the definition line of the panel
+func (d *Dialect) panelLine(pn *Panel) string {
+	return formatMarker(d.PanelPrefix, withAttrs(pn.ID, pn.Attrs))
+}
+
+// item returns the markup of a panel item. Only pre-formatted text lines
+// can span more than one line.
+func (d *Dialect) item(item Item) string {
+	switch i := item.(type) {
+	case *TextLine:
+		return d.textLine(i, " ")
+	case *SoundEffect:
+		return d.soundEffect(i)
+	case *SideNote:
+		return formatMarker(d.SideNotePrefix, i.Content)
+	}
+	panic(fmt.Sprintf("serifu: unknown item type %T", item))
+}
+
+// textLine returns the markup of t using space between the heading and the
+// content
+func (d *Dialect) textLine(t *TextLine, space string) string {
+	var b strings.Builder
+	if t.Connected {
+		b.WriteString(d.ConnectedPrefix + " ")
+	}
+	b.WriteString(t.Source)
+	if t.Style != "" {
+		b.WriteString(d.StyleSeparator)
+		b.WriteString(t.Style)
+	}
+	if t.LineKind != DialogueLine {
+		if b.Len() > 0 {
+			b.WriteByte(' ')
+		}
+		b.WriteString("(" + string(t.LineKind) + ")")
+	}
+	b.WriteString(d.TextLineSeparator)
+	attrs := formatAttrs(t.Attrs)
+	switch {
+	case t.IsPreFormatted && strings.Contains(t.Content, "\n"):
+		// the attributes of a block are on its first line
+		b.WriteString(d.PreFormattedBlockStart)
+		if attrs != "" {
+			b.WriteString(" " + attrs)
+		}
+		b.WriteByte('\n')
+		b.WriteString(t.Content)
+		b.WriteString(d.PreFormattedBlockEnd)
+		return b.String()
+	case t.IsPreFormatted:
+		b.WriteString(d.PreFormattedBlockStart)
+		b.WriteString(t.Content)
+		b.WriteString(d.PreFormattedBlockEnd)
+	case t.Content != "":
+		b.WriteString(space)
+		b.WriteString(t.Content)
+	}
+	if attrs != "" {
+		b.WriteString(" " + attrs)
+	}
+	return b.String()
+}
+
+func (d *Dialect) soundEffect(se *SoundEffect) string {
+	return formatMarker(d.SoundPrefix, withAttrs(formatSoundEffectText(se), se.Attrs))
+}
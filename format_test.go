@@ -0,0 +1,159 @@
+package serifu
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const formatInput = `! chapter 31 of the French edition
+
+# PAGE 1
+! this page is mirrored
+- 1.1
+- 1.2 {x=10, y=20}
+(caption): Meanwhile
+Menelaus/Announcing (off-panel): Here in the mountains of Japan…
+Menelaus/Announcing:
+* gasp (haa)
+! he is not really serious
+- 1.3
+Contract Text:/= The undersigned* agrees to sell his soul**=/
+Sign:/=
+Menu:
+  - Beer: 200 Yen
+=/
+Note:/=  indented
+last line=/
+Empty:/==/
+
+## PAGE 2
+- 2.1
+Palawan: Hi
+`
+
+// withoutRanges returns a copy of s with all source positions cleared so
+// scripts parsed from different layouts can be compared
+func withoutRanges(s *Script) *Script {
+	c := &Script{Pages: make([]*Page, 0, len(s.Pages))}
+	if s.Meta != nil {
+		cm := *s.Meta
+		cm.Range = Range{}
+		c.Meta = &cm
+	}
+	c.Notes = notesWithoutRanges(s.Notes)
+	for _, p := range s.Pages {
+		cp := *p
+		cp.Range = Range{}
+		cp.Notes = notesWithoutRanges(p.Notes)
+		cp.Panels = nil
+		for _, pn := range p.Panels {
+			cpn := *pn
+			cpn.Range = Range{}
+			cpn.Items = nil
+			for _, item := range pn.Items {
+				switch i := item.(type) {
+				case *TextLine:
+					ci := *i
+					ci.Range = Range{}
+					cpn.Items = append(cpn.Items, &ci)
+				case *SideNote:
+					ci := *i
+					ci.Range = Range{}
+					cpn.Items = append(cpn.Items, &ci)
+				case *SoundEffect:
+					ci := *i
+					ci.Range = Range{}
+					cpn.Items = append(cpn.Items, &ci)
+				}
+			}
+			cp.Panels = append(cp.Panels, &cpn)
+		}
+		c.Pages = append(c.Pages, &cp)
+	}
+	return c
+}
+
+func notesWithoutRanges(notes []*SideNote) []*SideNote {
+	var c []*SideNote
+	for _, n := range notes {
+		cn := *n
+		cn.Range = Range{}
+		c = append(c, &cn)
+	}
+	return c
+}
+
+func TestFormat_roundTrip(t *testing.T) {
+	want, err := Parse(strings.NewReader(formatInput))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	tests := []struct {
+		name string
+		opts FormatOptions
+	}{
+		{"canonical", FormatOptions{}},
+		{"indented", FormatOptions{Indent: "    "}},
+		{"tab indented", FormatOptions{Indent: "\t"}},
+		{"blank lines between panels", FormatOptions{BlankLinesBetweenPanels: 2}},
+		{"no space after colon", FormatOptions{NoSpaceAfterColon: true}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var b strings.Builder
+			if err := Format(&b, want, tt.opts); err != nil {
+				t.Fatalf("Format() error = %v", err)
+			}
+			got, err := Parse(strings.NewReader(b.String()))
+			if err != nil {
+				t.Fatalf("Parse(Format()) error = %v\n%s", err, b.String())
+			}
+			if !reflect.DeepEqual(withoutRanges(got), withoutRanges(want)) {
+				t.Errorf("Parse(Format()) = %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestFormat(t *testing.T) {
+	s := &Script{Pages: []*Page{
+		{Title: "PAGE 1", Panels: []*Panel{
+			{ID: "1.1", Items: Items{
+				&TextLine{Source: "Shota", Style: "Sharp", Content: "Hi"},
+				&SoundEffect{Name: "gasp", Transliteration: "haa"},
+			}},
+			{ID: "1.2", Items: Items{
+				&TextLine{Source: "Sign", IsPreFormatted: true, Content: "a\nb\n"},
+			}},
+		}},
+		{Title: "PAGE 2", IsSpread: true},
+	}}
+	tests := []struct {
+		name string
+		opts FormatOptions
+		want string
+	}{
+		{
+			"canonical",
+			FormatOptions{},
+			"# PAGE 1\n- 1.1\nShota/Sharp: Hi\n* gasp (haa)\n- 1.2\nSign:/=\na\nb\n=/\n\n## PAGE 2\n",
+		},
+		{
+			"all options",
+			FormatOptions{Indent: "  ", BlankLinesBetweenPanels: 1, NoSpaceAfterColon: true},
+			"# PAGE 1\n  - 1.1\n    Shota/Sharp:Hi\n    * gasp (haa)\n\n  - 1.2\n    Sign:/=\na\nb\n=/\n\n## PAGE 2\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var b strings.Builder
+			if err := Format(&b, s, tt.opts); err != nil {
+				t.Fatalf("Format() error = %v", err)
+			}
+			if got := b.String(); got != tt.want {
+				t.Errorf("Format() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,269 @@
+package serifu
+
+import (
+	"html"
+	"io"
+	"sort"
+	"strings"
+)
+
+// DefaultHTMLStylesheet is the stylesheet embedded by RenderHTML
+const DefaultHTMLStylesheet = `body { font-family: sans-serif; max-width: 50em; margin: 2em auto; color: #222; }
+.serifu-meta dl { display: grid; grid-template-columns: max-content auto; gap: 0.2em 1em; }
+.serifu-meta dt { font-weight: bold; text-transform: capitalize; }
+.serifu-meta dd { margin: 0; }
+.serifu-page { border-top: 2px solid #222; margin-top: 2em; }
+.serifu-spread { border-top-style: double; border-top-width: 6px; }
+.serifu-spread-label { font-size: 0.6em; background: #222; color: #fff; padding: 0.1em 0.4em; margin-left: 0.5em; vertical-align: middle; }
+.serifu-panel { margin: 1em 0 1em 1em; }
+.serifu-panel h3 { font-size: 1em; color: #666; margin: 0.5em 0; }
+.serifu-line { margin: 0.3em 0; }
+.serifu-connected { border-left: 2px solid #999; padding-left: 0.5em; }
+.serifu-caption, .serifu-narration { border: 1px solid #222; padding: 0.2em 0.5em; }
+.serifu-thought .serifu-content { font-style: italic; }
+.serifu-whisper .serifu-content { color: #666; }
+.serifu-off-panel .serifu-source::after { content: " (off-panel)"; color: #666; font-weight: normal; }
+.serifu-source { font-weight: bold; }
+.serifu-style { color: #666; font-style: italic; }
+.serifu-style::before { content: "("; }
+.serifu-style::after { content: ")"; }
+.serifu-pre { background: #f4f4f4; padding: 0.5em; white-space: pre-wrap; }
+.serifu-sfx { font-family: serif; letter-spacing: 0.1em; }
+.serifu-sfx-name { font-weight: bold; text-transform: uppercase; }
+.serifu-sfx-reading { color: #666; }
+.serifu-sfx-placement { color: #666; font-size: 0.8em; }
+.serifu-sfx-placement::before { content: "@ "; }
+.serifu-note { border-left: 4px solid #e0a800; background: #fff8e1; padding: 0.3em 0.6em; margin: 0.5em 0; }
+`
+
+// HTMLOptions controls the output of RenderHTML
+type HTMLOptions struct {
+	// Title is the title of the document
+	Title string
+	// Fragment writes only the script element without the surrounding
+	// html, head and body elements
+	Fragment bool
+	// EmbedStylesheet writes DefaultHTMLStylesheet, or Stylesheet when it is
+	// set, into the document so the output is a single self-contained file
+	EmbedStylesheet bool
+	// Stylesheet replaces the default stylesheet
+	Stylesheet string
+}
+
+// RenderHTML writes s to w as an HTML document for review
+func RenderHTML(w io.Writer, s *Script, opts HTMLOptions) error {
+	r := &htmlRenderer{w: w}
+	if !opts.Fragment {
+		title := opts.Title
+		if title == "" && s.Meta != nil {
+			title = strings.TrimSpace(s.Meta.Series + " " + s.Meta.Chapter)
+		}
+		r.raw("<!DOCTYPE html>\n<html>\n<head>\n<meta charset=\"utf-8\">\n")
+		r.raw("<title>" + html.EscapeString(title) + "</title>\n")
+		if opts.EmbedStylesheet {
+			css := opts.Stylesheet
+			if css == "" {
+				css = DefaultHTMLStylesheet
+			}
+			r.raw("<style>\n" + css + "</style>\n")
+		}
+		r.raw("</head>\n<body>\n")
+	}
+	r.raw("<article class=\"serifu-script\">\n")
+	if s.Meta != nil {
+		r.meta(s.Meta)
+	}
+	for _, n := range s.Notes {
+		r.note(n)
+	}
+	for _, p := range s.Pages {
+		r.page(p)
+	}
+	r.raw("</article>\n")
+	if !opts.Fragment {
+		r.raw("</body>\n</html>\n")
+	}
+	return r.err
+}
+
+// htmlRenderer writes HTML remembering the first write error
+type htmlRenderer struct {
+	w   io.Writer
+	err error
+}
+
+func (r *htmlRenderer) raw(s string) {
+	if r.err != nil {
+		return
+	}
+	_, r.err = io.WriteString(r.w, s)
+}
+
+func (r *htmlRenderer) text(s string) {
+	r.raw(html.EscapeString(s))
+}
+
+func (r *htmlRenderer) meta(m *Meta) {
+	r.raw("<header class=\"serifu-meta\">\n<dl>\n")
+	for _, f := range m.Fields() {
+		r.raw("<dt>")
+		r.text(f[0])
+		r.raw("</dt><dd>")
+		r.text(f[1])
+		r.raw("</dd>\n")
+	}
+	r.raw("</dl>\n</header>\n")
+}
+
+func (r *htmlRenderer) page(p *Page) {
+	if p.IsSpread {
+		r.raw("<section class=\"serifu-page serifu-spread\"" + htmlAttrs(p.Attrs) + ">\n<h2>")
+		r.text(p.Title)
+		r.raw("<span class=\"serifu-spread-label\">Spread</span></h2>\n")
+	} else {
+		r.raw("<section class=\"serifu-page\"" + htmlAttrs(p.Attrs) + ">\n<h2>")
+		r.text(p.Title)
+		r.raw("</h2>\n")
+	}
+	for _, n := range p.Notes {
+		r.note(n)
+	}
+	for _, pn := range p.Panels {
+		r.panel(pn)
+	}
+	r.raw("</section>\n")
+}
+
+func (r *htmlRenderer) panel(pn *Panel) {
+	r.raw("<div class=\"serifu-panel\"" + htmlAttrs(pn.Attrs) + ">\n<h3>")
+	r.text(pn.ID)
+	r.raw("</h3>\n")
+	for _, item := range pn.Items {
+		switch i := item.(type) {
+		case *TextLine:
+			r.textLine(i)
+		case *SoundEffect:
+			r.soundEffect(i)
+		case *SideNote:
+			r.note(i)
+		}
+	}
+	r.raw("</div>\n")
+}
+
+func (r *htmlRenderer) note(n *SideNote) {
+	r.raw("<aside class=\"serifu-note\">")
+	r.text(n.Content)
+	r.raw("</aside>\n")
+}
+
+func (r *htmlRenderer) textLine(t *TextLine) {
+	class := "serifu-line"
+	if t.LineKind != DialogueLine {
+		class += " serifu-" + string(t.LineKind)
+	}
+	if t.Connected {
+		class += " serifu-connected"
+	}
+	if t.IsPreFormatted {
+		r.raw("<div class=\"" + class + "\"" + htmlAttrs(t.Attrs) + ">")
+	} else {
+		r.raw("<p class=\"" + class + "\"" + htmlAttrs(t.Attrs) + ">")
+	}
+	if t.Source != "" {
+		r.raw("<span class=\"serifu-source\">")
+		r.text(t.Source)
+		r.raw("</span>")
+	}
+	if t.Style != "" {
+		r.raw(" <span class=\"serifu-style\">")
+		r.text(t.Style)
+		r.raw("</span>")
+	}
+	if t.IsPreFormatted {
+		r.raw("<pre class=\"serifu-pre\">")
+		r.text(t.Content)
+		r.raw("</pre></div>\n")
+		return
+	}
+	if t.Source != "" || t.Style != "" {
+		r.raw(" ")
+	}
+	r.raw("<span class=\"serifu-content\">")
+	spans := t.Spans
+	if spans == nil {
+		spans = ParseInline(t.Content)
+	}
+	r.spans(spans)
+	r.raw("</span></p>\n")
+}
+
+func (r *htmlRenderer) spans(spans []*Span) {
+	for _, s := range spans {
+		switch s.Kind {
+		case ItalicSpan:
+			r.raw("<em>")
+			r.spans(s.Children)
+			r.raw("</em>")
+		case BoldSpan:
+			r.raw("<strong>")
+			r.spans(s.Children)
+			r.raw("</strong>")
+		case BoldItalicSpan:
+			r.raw("<strong><em>")
+			r.spans(s.Children)
+			r.raw("</em></strong>")
+		case RubySpan:
+			r.raw("<ruby>")
+			r.text(s.Text)
+			r.raw("<rp>(</rp><rt>")
+			r.text(s.Ruby)
+			r.raw("</rt><rp>)</rp></ruby>")
+		default:
+			r.text(s.Text)
+		}
+	}
+}
+
+func (r *htmlRenderer) soundEffect(se *SoundEffect) {
+	r.raw("<p class=\"serifu-sfx\"" + htmlAttrs(se.Attrs) + ">SFX: <span class=\"serifu-sfx-name\">")
+	r.text(se.Name)
+	r.raw("</span>")
+	if se.Original != "" {
+		r.raw(" <span class=\"serifu-sfx-original\">")
+		r.text(se.Original)
+		r.raw("</span>")
+	}
+	if se.Transliteration != "" {
+		r.raw(" <span class=\"serifu-sfx-reading\">(")
+		r.text(se.Transliteration)
+		r.raw(")</span>")
+	}
+	if se.Placement != "" {
+		r.raw(" <span class=\"serifu-sfx-placement\">")
+		r.text(se.Placement)
+		r.raw("</span>")
+	}
+	r.raw("</p>\n")
+}
+
+// htmlAttrs returns attrs as data attributes sorted by key. Characters not
+// allowed in attribute names are replaced by dashes.
+func htmlAttrs(attrs Attrs) string {
+	keys := make([]string, 0, len(attrs))
+	for k := range attrs {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var b strings.Builder
+	for _, k := range keys {
+		name := strings.Map(func(r rune) rune {
+			if r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r == '-' || r == '_' || r == '.' {
+				return r
+			}
+			return '-'
+		}, strings.ToLower(k))
+		b.WriteString(" data-" + name + "=\"" + html.EscapeString(attrs[k]) + "\"")
+	}
+	return b.String()
+}
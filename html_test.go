@@ -0,0 +1,94 @@
+package serifu
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRenderHTML(t *testing.T) {
+	s, err := Parse(strings.NewReader(`---
+series: Moriking
+chapter: 31
+---
+! French edition
+## PAGE 1 & 2
+! mirrored
+- 1.1 {status=Done, Font Size=12}
+Shota/Sharp: A _death match?!?_ <b> {死闘|shitou}
+* gasp (はあ | haa) [top right]
+! he is not really serious
+(caption): Later
+& (caption):
+  much later
+Sign:/=
+Menu: <1 Yen>
+=/
+`))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	tests := []struct {
+		name string
+		opts HTMLOptions
+		want string
+	}{
+		{
+			"fragment",
+			HTMLOptions{Fragment: true},
+			`<article class="serifu-script">
+<header class="serifu-meta">
+<dl>
+<dt>series</dt><dd>Moriking</dd>
+<dt>chapter</dt><dd>31</dd>
+</dl>
+</header>
+<aside class="serifu-note">French edition</aside>
+<section class="serifu-page serifu-spread">
+<h2>PAGE 1 &amp; 2<span class="serifu-spread-label">Spread</span></h2>
+<aside class="serifu-note">mirrored</aside>
+<div class="serifu-panel" data-font-size="12" data-status="Done">
+<h3>1.1</h3>
+<p class="serifu-line"><span class="serifu-source">Shota</span> <span class="serifu-style">Sharp</span> <span class="serifu-content">A <em>death match?!?</em> &lt;b&gt; <ruby>死闘<rp>(</rp><rt>shitou</rt><rp>)</rp></ruby></span></p>
+<p class="serifu-sfx">SFX: <span class="serifu-sfx-name">gasp</span> <span class="serifu-sfx-original">はあ</span> <span class="serifu-sfx-reading">(haa)</span> <span class="serifu-sfx-placement">top right</span></p>
+<aside class="serifu-note">he is not really serious</aside>
+<p class="serifu-line serifu-caption"><span class="serifu-content">Later</span></p>
+<p class="serifu-line serifu-caption serifu-connected"><span class="serifu-content">much later</span></p>
+<div class="serifu-line"><span class="serifu-source">Sign</span><pre class="serifu-pre">Menu: &lt;1 Yen&gt;
+</pre></div>
+</div>
+</section>
+</article>
+`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var b strings.Builder
+			if err := RenderHTML(&b, s, tt.opts); err != nil {
+				t.Fatalf("RenderHTML() error = %v", err)
+			}
+			if got := b.String(); got != tt.want {
+				t.Errorf("RenderHTML() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRenderHTML_document(t *testing.T) {
+	var b strings.Builder
+	err := RenderHTML(&b, &Script{}, HTMLOptions{Title: "Chapter <31>", EmbedStylesheet: true})
+	if err != nil {
+		t.Fatalf("RenderHTML() error = %v", err)
+	}
+	got := b.String()
+	for _, want := range []string{
+		"<!DOCTYPE html>",
+		"<title>Chapter &lt;31&gt;</title>",
+		"<style>\n" + DefaultHTMLStylesheet + "</style>",
+		"</html>\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("RenderHTML() = %v, want to contain %q", got, want)
+		}
+	}
+}
@@ -0,0 +1,224 @@
+package serifu
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// SpanKind is the kind of a rich text span
+type SpanKind string
+
+const (
+	// TextSpan is plain text
+	TextSpan SpanKind = "text"
+	// ItalicSpan is text between underscores: _text_
+	ItalicSpan SpanKind = "italic"
+	// BoldSpan is text between double asterisks: **text**
+	BoldSpan SpanKind = "bold"
+	// BoldItalicSpan is text between triple asterisks: ***text***
+	BoldItalicSpan SpanKind = "boldItalic"
+	// EscapeSpan is a punctuation character escaped with a backslash: \*
+	EscapeSpan SpanKind = "escape"
+	// RubySpan is base text with a reading or gloss above it: {漢字|かんじ}
+	RubySpan SpanKind = "ruby"
+)
+
+const (
+	rubyStart     = '{'
+	rubySeparator = '|'
+	rubyEnd       = '}'
+)
+
+// Span is a node of the rich text tree of a text line. Text, escape and
+// ruby spans carry Text, the emphasis spans carry Children. Ruby spans carry
+// the annotation of their text in Ruby.
+type Span struct {
+	Kind     SpanKind `json:"kind"`
+	Text     string   `json:"text,omitempty"`
+	Ruby     string   `json:"ruby,omitempty"`
+	Children []*Span  `json:"children,omitempty"`
+}
+
+// inlineMarkers are the emphasis markers, longest first
+var inlineMarkers = []struct {
+	marker string
+	kind   SpanKind
+}{
+	{"***", BoldItalicSpan},
+	{"**", BoldSpan},
+	{"_", ItalicSpan},
+}
+
+// ParseInline parses the inline markup of text line content into a span
+// tree.
+//
+// A marker opens a span only when it is followed by a non-space character
+// and closes it only when it follows one. Underscores inside words are
+// never markers. Markers without a matching closing marker, and empty
+// spans, are kept as plain text. A backslash before an ASCII punctuation
+// character makes it literal.
+//
+// Ruby is written in braces with the base text and the annotation separated
+// by a bar: {漢字|かんじ}. Both parts are plain text and must not be empty.
+func ParseInline(s string) []*Span {
+	p := &inlineParser{s: s, spans: make(map[spanStart]spanResult)}
+	spans, _, _ := p.parseSpans(0, "")
+	return spans
+}
+
+// inlineParser parses inline markup. The span opened at a position by a
+// marker is the same whatever encloses it, so spans remembers the result of
+// every opened span and a line with many unclosed markers is not rescanned
+// for each combination of them.
+type inlineParser struct {
+	s     string
+	spans map[spanStart]spanResult
+}
+
+// spanStart is a position where a marker opened a span
+type spanStart struct {
+	i    int
+	stop string
+}
+
+// spanResult is the result of parseSpans for a spanStart
+type spanResult struct {
+	spans []*Span
+	next  int
+	ok    bool
+}
+
+// span parses the span opened by the marker stop before i
+func (p *inlineParser) span(i int, stop string) ([]*Span, int, bool) {
+	key := spanStart{i, stop}
+	r, ok := p.spans[key]
+	if !ok {
+		r.spans, r.next, r.ok = p.parseSpans(i, stop)
+		p.spans[key] = r
+	}
+	return r.spans, r.next, r.ok
+}
+
+// parseSpans parses s from i until the closing marker stop. It returns the
+// spans, the position after the closing marker and if it was found.
+func (p *inlineParser) parseSpans(i int, stop string) ([]*Span, int, bool) {
+	s := p.s
+	var spans []*Span
+	var text strings.Builder
+	flush := func() {
+		if text.Len() > 0 {
+			spans = append(spans, &Span{Kind: TextSpan, Text: text.String()})
+			text.Reset()
+		}
+	}
+	for i < len(s) {
+		if stop != "" && strings.HasPrefix(s[i:], stop) && canClose(s, i, stop) {
+			flush()
+			return spans, i + len(stop), true
+		}
+		if s[i] == '\\' && i+1 < len(s) && isASCIIPunct(s[i+1]) {
+			flush()
+			spans = append(spans, &Span{Kind: EscapeSpan, Text: s[i+1 : i+2]})
+			i += 2
+			continue
+		}
+		if s[i] == rubyStart {
+			if span, next := parseRuby(s, i); span != nil {
+				flush()
+				spans = append(spans, span)
+				i = next
+				continue
+			}
+		}
+		if m, kind := markerAt(s, i); m != "" {
+			children, next, ok := p.span(i+len(m), m)
+			if ok && len(children) > 0 {
+				flush()
+				spans = append(spans, &Span{Kind: kind, Children: children})
+				i = next
+				continue
+			}
+			text.WriteString(m)
+			i += len(m)
+			continue
+		}
+		text.WriteByte(s[i])
+		i++
+	}
+	flush()
+	return spans, i, stop == ""
+}
+
+// parseRuby parses the ruby span starting at s[i]. It returns nil when
+// there is none.
+func parseRuby(s string, i int) (*Span, int) {
+	end := strings.IndexByte(s[i+1:], rubyEnd)
+	if end < 0 {
+		return nil, i
+	}
+	inner := s[i+1 : i+1+end]
+	sep := strings.IndexByte(inner, rubySeparator)
+	if sep < 0 || strings.IndexByte(inner, rubyStart) > -1 {
+		return nil, i
+	}
+	base := strings.TrimSpace(inner[:sep])
+	ruby := strings.TrimSpace(inner[sep+1:])
+	if base == "" || ruby == "" {
+		return nil, i
+	}
+	return &Span{Kind: RubySpan, Text: base, Ruby: ruby}, i + end + 2
+}
+
+// markerAt returns the marker opening a span at s[i:]
+func markerAt(s string, i int) (string, SpanKind) {
+	for _, m := range inlineMarkers {
+		if strings.HasPrefix(s[i:], m.marker) && canOpen(s, i, m.marker) {
+			return m.marker, m.kind
+		}
+	}
+	return "", ""
+}
+
+func canOpen(s string, i int, marker string) bool {
+	next, _ := utf8.DecodeRuneInString(s[i+len(marker):])
+	if next == utf8.RuneError || unicode.IsSpace(next) {
+		return false
+	}
+	if marker == "_" {
+		prev, _ := utf8.DecodeLastRuneInString(s[:i])
+		return !isWordRune(prev)
+	}
+	return true
+}
+
+func canClose(s string, i int, marker string) bool {
+	prev, _ := utf8.DecodeLastRuneInString(s[:i])
+	if prev == utf8.RuneError || unicode.IsSpace(prev) {
+		return false
+	}
+	if marker == "_" {
+		next, _ := utf8.DecodeRuneInString(s[i+len(marker):])
+		return !isWordRune(next)
+	}
+	return true
+}
+
+func isWordRune(r rune) bool {
+	return r != utf8.RuneError && (unicode.IsLetter(r) || unicode.IsDigit(r))
+}
+
+func isASCIIPunct(c byte) bool {
+	return strings.IndexByte("!\"#$%&'()*+,-./:;<=>?@[\\]^_`{|}~", c) > -1
+}
+
+// InlineText returns the text of spans without the markup. Ruby
+// annotations are left out.
+func InlineText(spans []*Span) string {
+	var b strings.Builder
+	for _, s := range spans {
+		b.WriteString(s.Text)
+		b.WriteString(InlineText(s.Children))
+	}
+	return b.String()
+}
@@ -0,0 +1,106 @@
+package serifu
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+// spanString returns a compact description of spans for comparisons
+func spanString(spans []*Span) string {
+	var b strings.Builder
+	for _, s := range spans {
+		switch s.Kind {
+		case TextSpan:
+			b.WriteString(s.Text)
+		case EscapeSpan:
+			b.WriteString("\\" + s.Text)
+		case RubySpan:
+			b.WriteString("ruby(" + s.Text + "|" + s.Ruby + ")")
+		default:
+			b.WriteString(string(s.Kind) + "(" + spanString(s.Children) + ")")
+		}
+	}
+	return b.String()
+}
+
+func TestParseInline(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"plain", "Hold up.", "Hold up."},
+		{"italic", "A _death match?!?_ The", "A italic(death match?!?) The"},
+		{"bold", "such **pathetic games.**", "such bold(pathetic games.)"},
+		{"bold italic", "***now***!", "boldItalic(now)!"},
+		{"nested", "_so **very** cool_", "italic(so bold(very) cool)"},
+		{"unbalanced opener", "a **b c", "a **b c"},
+		{"unbalanced closer", "a b** c", "a b** c"},
+		{"marker before space", "soul** for a thousand berries.***", "soul** for a thousand berries.***"},
+		{"empty span", "****", "****"},
+		{"intraword underscore", "snake_case_name", "snake_case_name"},
+		{"unclosed inside bold", "**bold _it**", "bold(bold _it)"},
+		{"closer taken by nested span", "_a _b_", "_a italic(b)"},
+		{"unclosed before span", "_a **b _c_**", "_a bold(b italic(c))"},
+		{"escape", `\*\*not bold\*\* \_x\_`, `\*\*not bold\*\* \_x\_`},
+		{"backslash before letter", `C:\path`, `C:\path`},
+		{"multibyte", "_日本_語", "_日本_語"},
+		{"multibyte spaced", "「_日本_」", "「italic(日本)」"},
+		{"ruby", "{漢字|かんじ}を読む", "ruby(漢字|かんじ)を読む"},
+		{"ruby gloss", "a { frobnitz | FROB-nits } sound", "a ruby(frobnitz|FROB-nits) sound"},
+		{"ruby inside italic", "_the {Kumo|spider}_", "italic(the ruby(Kumo|spider))"},
+		{"ruby without annotation", "{base|} {|ann}", "{base|} {|ann}"},
+		{"braces without bar", "{not ruby}", "{not ruby}"},
+		{"escaped ruby", `\{a|b}`, `\{a|b}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := spanString(ParseInline(tt.input)); got != tt.want {
+				t.Errorf("ParseInline() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseInline_unclosedMarkers(t *testing.T) {
+	// every unclosed marker used to rescan the rest of the line for each
+	// later one, taking exponential time
+	s := strings.Repeat("_a ", 1000) + strings.Repeat("**b ", 1000)
+	done := make(chan string)
+	go func() { done <- spanString(ParseInline(s)) }()
+	select {
+	case got := <-done:
+		if got != s {
+			t.Errorf("ParseInline() changed the unclosed markers")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("ParseInline() did not finish in 5s")
+	}
+}
+
+func TestInlineText(t *testing.T) {
+	if got, want := InlineText(ParseInline(`A _**b**_ \* {c|see}`)), "A b * c"; got != want {
+		t.Errorf("InlineText() = %q, want %q", got, want)
+	}
+}
+
+func TestParseWithOptions_inlineMarkup(t *testing.T) {
+	input := "# PAGE 1\n- 1.1\nShota: A _death_\nSign:/=**x**=/\n"
+	got, err := ParseWithOptions(strings.NewReader(input), ParserOptions{InlineMarkup: true})
+	if err != nil {
+		t.Fatalf("ParseWithOptions() error = %v", err)
+	}
+	items := got.Pages[0].Panels[0].Items
+	if s := spanString(items[0].(*TextLine).Spans); s != "A italic(death)" {
+		t.Errorf("Spans = %q, want %q", s, "A italic(death)")
+	}
+	if spans := items[1].(*TextLine).Spans; spans != nil {
+		t.Errorf("pre-formatted Spans = %v, want nil", spans)
+	}
+	data, _ := json.Marshal(items[0])
+	if !strings.Contains(string(data), `"spans":[{"kind":"text","text":"A "},{"kind":"italic","children":[{"kind":"text","text":"death"}]}]`) {
+		t.Errorf("json = %s", data)
+	}
+}
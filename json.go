@@ -0,0 +1,124 @@
+package serifu
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// JSONVersion is the version of the JSON envelope written by EncodeJSON
+const JSONVersion = 1
+
+// Envelope is the versioned JSON representation of a script
+type Envelope struct {
+	Version int     `json:"version"`
+	Script  *Script `json:"script"`
+}
+
+// EncodeJSON writes s to w wrapped in an Envelope
+func EncodeJSON(w io.Writer, s *Script) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(Envelope{JSONVersion, s})
+}
+
+// DecodeJSON reads a script written by EncodeJSON. Scripts marshaled
+// without an envelope are accepted too.
+func DecodeJSON(r io.Reader) (*Script, error) {
+	var doc struct {
+		Version *int            `json:"version"`
+		Script  json.RawMessage `json:"script"`
+	}
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	if doc.Version == nil {
+		// bare script
+		var s Script
+		if err := json.Unmarshal(data, &s); err != nil {
+			return nil, err
+		}
+		return &s, nil
+	}
+	if *doc.Version < 1 || *doc.Version > JSONVersion {
+		return nil, fmt.Errorf("unsupported JSON version %d", *doc.Version)
+	}
+	var s Script
+	if err := json.Unmarshal(doc.Script, &s); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// newItem returns an empty item of the given type
+func newItem(t ItemType) (Item, error) {
+	switch t {
+	case TextLineItemType:
+		return &TextLine{}, nil
+	case SoundEffectItemType:
+		return &SoundEffect{}, nil
+	case SideNoteItemType:
+		return &SideNote{}, nil
+	}
+	return nil, fmt.Errorf("unknown item type %q", t)
+}
+
+// MarshalJSON encodes the text line with its Kind as type whatever its Type
+// field holds
+func (t *TextLine) MarshalJSON() ([]byte, error) {
+	type textLine TextLine
+	c := *(*textLine)(t)
+	c.Type = t.Kind()
+	return json.Marshal(c)
+}
+
+// MarshalJSON encodes the sound effect with its Kind as type
+func (se *SoundEffect) MarshalJSON() ([]byte, error) {
+	type soundEffect SoundEffect
+	c := *(*soundEffect)(se)
+	c.Type = se.Kind()
+	return json.Marshal(c)
+}
+
+// MarshalJSON encodes the side note with its Kind as type
+func (n *SideNote) MarshalJSON() ([]byte, error) {
+	type sideNote SideNote
+	c := *(*sideNote)(n)
+	c.Type = n.Kind()
+	return json.Marshal(c)
+}
+
+// UnmarshalJSON decodes the panel using the type field of every item to
+// choose its Go type
+func (pn *Panel) UnmarshalJSON(data []byte) error {
+	type panel Panel
+	aux := struct {
+		*panel
+		Items []json.RawMessage `json:"items"`
+	}{panel: (*panel)(pn)}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	pn.Items = nil
+	for _, raw := range aux.Items {
+		var head struct {
+			Type ItemType `json:"type"`
+		}
+		if err := json.Unmarshal(raw, &head); err != nil {
+			return err
+		}
+		item, err := newItem(head.Type)
+		if err != nil {
+			return err
+		}
+		if err := json.Unmarshal(raw, item); err != nil {
+			return err
+		}
+		pn.Items = append(pn.Items, item)
+	}
+	return nil
+}
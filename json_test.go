@@ -0,0 +1,105 @@
+package serifu
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestPanel_UnmarshalJSON(t *testing.T) {
+	want, err := Parse(strings.NewReader(formatInput))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	data, err := json.Marshal(want)
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+	var got Script
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("json.Unmarshal() error = %v", err)
+	}
+	if !reflect.DeepEqual(&got, withoutRanges(want)) {
+		t.Errorf("json.Unmarshal() = %v, want %v", &got, want)
+	}
+}
+
+func TestEncodeJSON_itemsWithoutType(t *testing.T) {
+	s := &Script{
+		Notes: []*SideNote{{Content: "script"}},
+		Pages: []*Page{{Title: "PAGE 1", Panels: []*Panel{{ID: "1", Items: Items{
+			&TextLine{Source: "Shota", Content: "Hi"},
+			&SoundEffect{Name: "BAM"},
+			&SideNote{Content: "x"},
+		}}}}},
+	}
+	var b strings.Builder
+	if err := EncodeJSON(&b, s); err != nil {
+		t.Fatalf("EncodeJSON() error = %v", err)
+	}
+	got, err := DecodeJSON(strings.NewReader(b.String()))
+	if err != nil {
+		t.Fatalf("DecodeJSON() error = %v", err)
+	}
+	want := &Script{
+		Notes: []*SideNote{{Type: SideNoteItemType, Content: "script"}},
+		Pages: []*Page{{Title: "PAGE 1", Panels: []*Panel{{ID: "1", Items: Items{
+			&TextLine{Type: TextLineItemType, Source: "Shota", Content: "Hi"},
+			&SoundEffect{Type: SoundEffectItemType, Name: "BAM"},
+			&SideNote{Type: SideNoteItemType, Content: "x"},
+		}}}}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("DecodeJSON() = %v, want %v", got, want)
+	}
+}
+
+func TestPanel_UnmarshalJSON_unknownType(t *testing.T) {
+	var pn Panel
+	err := json.Unmarshal([]byte(`{"id": "1.1", "items": [{"type": "balloon"}]}`), &pn)
+	if err == nil {
+		t.Errorf("json.Unmarshal() error = nil, want error")
+	}
+}
+
+func TestDecodeJSON(t *testing.T) {
+	script := &Script{Pages: []*Page{
+		{Title: "PAGE 1", Panels: []*Panel{
+			{ID: "1.1", Items: Items{
+				&TextLine{Type: TextLineItemType, Source: "Shota", Content: "Hi"},
+				&SoundEffect{Type: SoundEffectItemType, Name: "gasp"},
+			}},
+		}},
+	}}
+	var envelope strings.Builder
+	if err := EncodeJSON(&envelope, script); err != nil {
+		t.Fatalf("EncodeJSON() error = %v", err)
+	}
+	bare, err := json.Marshal(script)
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+	tests := []struct {
+		name    string
+		input   string
+		want    *Script
+		wantErr bool
+	}{
+		{"envelope", envelope.String(), script, false},
+		{"bare script", string(bare), script, false},
+		{"future version", `{"version": 99, "script": {"pages": []}}`, nil, true},
+		{"invalid", `{"version": 1, "script": [}`, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := DecodeJSON(strings.NewReader(tt.input))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("DecodeJSON() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("DecodeJSON() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,68 @@
+package lint
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/aquilax/serifu-go"
+)
+
+// Level is the configured level of a rule
+type Level string
+
+const (
+	// Off disables the rule
+	Off Level = "off"
+	// Warning reports the problems of the rule as warnings
+	Warning Level = "warning"
+	// Error reports the problems of the rule as errors
+	Error Level = "error"
+)
+
+func (l Level) severity() serifu.Severity {
+	if l == Error {
+		return serifu.SeverityError
+	}
+	return serifu.SeverityWarning
+}
+
+// Config enables and disables rules per project. It is stored as JSON:
+//
+//	{"rules": {"single-use-speaker": "off", "empty-text-line": "error"}}
+//
+// Rules not listed keep their default severity.
+type Config struct {
+	Rules map[string]Level `json:"rules"`
+}
+
+// ReadConfig reads a configuration from r
+func ReadConfig(r io.Reader) (*Config, error) {
+	var cfg Config
+	if err := json.NewDecoder(r).Decode(&cfg); err != nil {
+		return nil, err
+	}
+	for name, level := range cfg.Rules {
+		switch level {
+		case Off, Warning, Error:
+		default:
+			return nil, fmt.Errorf("rule %q: unknown level %q", name, level)
+		}
+	}
+	return &cfg, nil
+}
+
+// LoadConfig reads the configuration file name
+func LoadConfig(name string) (*Config, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	cfg, err := ReadConfig(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	return cfg, nil
+}
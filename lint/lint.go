@@ -0,0 +1,93 @@
+// Package lint finds suspicious but valid constructs in Serifu scripts
+package lint
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/aquilax/serifu-go"
+)
+
+// Problem is a finding reported by a rule
+type Problem struct {
+	Rule     string
+	Pos      serifu.Position
+	Severity serifu.Severity
+	Msg      string
+}
+
+func (p *Problem) String() string {
+	return fmt.Sprintf("%s: %s: %s (%s)", p.Pos, p.Severity, p.Msg, p.Rule)
+}
+
+// Reporter is called by a rule for every problem found
+type Reporter func(pos serifu.Position, msg string)
+
+// Rule checks a script for one kind of problem
+type Rule interface {
+	// Name is the identifier of the rule used in the configuration
+	Name() string
+	// Doc describes what the rule reports
+	Doc() string
+	// Severity is the severity used when the configuration does not set one
+	Severity() serifu.Severity
+	// Check reports the problems found in s
+	Check(s *serifu.Script, report Reporter)
+}
+
+// Linter runs a set of rules
+type Linter struct {
+	rules    []Rule
+	severity map[string]serifu.Severity
+}
+
+// New returns a linter running rules configured by cfg. A nil cfg enables
+// every rule with its default severity.
+func New(rules []Rule, cfg *Config) (*Linter, error) {
+	l := &Linter{severity: make(map[string]serifu.Severity)}
+	known := make(map[string]bool)
+	for _, r := range rules {
+		known[r.Name()] = true
+	}
+	if cfg != nil {
+		for name := range cfg.Rules {
+			if !known[name] {
+				return nil, fmt.Errorf("unknown rule %q", name)
+			}
+		}
+	}
+	for _, r := range rules {
+		sev := r.Severity()
+		if cfg != nil {
+			if level, ok := cfg.Rules[r.Name()]; ok {
+				if level == Off {
+					continue
+				}
+				sev = level.severity()
+			}
+		}
+		l.rules = append(l.rules, r)
+		l.severity[r.Name()] = sev
+	}
+	return l, nil
+}
+
+// Lint runs the rules on s and returns the problems ordered by position
+func (l *Linter) Lint(s *serifu.Script) []*Problem {
+	var problems []*Problem
+	for _, r := range l.rules {
+		name := r.Name()
+		r.Check(s, func(pos serifu.Position, msg string) {
+			problems = append(problems, &Problem{
+				Rule:     name,
+				Pos:      pos,
+				Severity: l.severity[name],
+				Msg:      msg,
+			})
+		})
+	}
+	sort.SliceStable(problems, func(i, j int) bool {
+		return problems[i].Pos.Offset < problems[j].Pos.Offset
+	})
+	return problems
+}
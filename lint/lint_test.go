@@ -0,0 +1,90 @@
+package lint
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/aquilax/serifu-go"
+)
+
+const script = `# PAGE 2
+- 2.1
+Shota: Hi
+Shota:
+- 2.1
+Shoko: Yo
+- 3.1
+Shoko: Hey
+Shtoa: Typo
+
+## PAGES 4-5
+- 5.1
+Shota: Wide
+
+# COVER
+`
+
+func lintScript(t *testing.T, cfg *Config) []string {
+	t.Helper()
+	s, err := serifu.Parse(strings.NewReader(script))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	l, err := New(DefaultRules(), cfg)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	var got []string
+	for _, p := range l.Lint(s) {
+		got = append(got, p.String())
+	}
+	return got
+}
+
+func TestLinter_Lint(t *testing.T) {
+	want := []string{
+		"4:1: warning: text line of \"Shota\" has no content (empty-text-line)",
+		"5:1: error: duplicate panel ID \"2.1\" on page \"PAGE 2\" (duplicate-panel-id)",
+		"7:1: warning: panel \"3.1\" does not belong to page \"PAGE 2\" (panel-page-mismatch)",
+		"9:1: warning: speaker \"Shtoa\" appears only once (single-use-speaker)",
+		"15:1: warning: page \"COVER\" has no panels (empty-page)",
+	}
+	if got := lintScript(t, nil); !reflect.DeepEqual(got, want) {
+		t.Errorf("Lint() = %q, want %q", got, want)
+	}
+}
+
+func TestLinter_Lint_config(t *testing.T) {
+	cfg, err := ReadConfig(strings.NewReader(`{"rules": {
+		"single-use-speaker": "off",
+		"empty-page": "off",
+		"panel-page-mismatch": "off",
+		"empty-text-line": "error",
+		"duplicate-panel-id": "warning"
+	}}`))
+	if err != nil {
+		t.Fatalf("ReadConfig() error = %v", err)
+	}
+	want := []string{
+		"4:1: error: text line of \"Shota\" has no content (empty-text-line)",
+		"5:1: warning: duplicate panel ID \"2.1\" on page \"PAGE 2\" (duplicate-panel-id)",
+	}
+	if got := lintScript(t, cfg); !reflect.DeepEqual(got, want) {
+		t.Errorf("Lint() = %q, want %q", got, want)
+	}
+}
+
+func TestNew_unknownRule(t *testing.T) {
+	_, err := New(DefaultRules(), &Config{Rules: map[string]Level{"nope": Off}})
+	if err == nil {
+		t.Errorf("New() error = nil, want error")
+	}
+}
+
+func TestReadConfig_unknownLevel(t *testing.T) {
+	_, err := ReadConfig(strings.NewReader(`{"rules": {"empty-page": "loud"}}`))
+	if err == nil {
+		t.Errorf("ReadConfig() error = nil, want error")
+	}
+}
@@ -0,0 +1,169 @@
+package lint
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/aquilax/serifu-go"
+)
+
+// rule is a Rule implemented by a function
+type rule struct {
+	name     string
+	doc      string
+	severity serifu.Severity
+	check    func(s *serifu.Script, report Reporter)
+}
+
+func (r *rule) Name() string                            { return r.name }
+func (r *rule) Doc() string                             { return r.doc }
+func (r *rule) Severity() serifu.Severity               { return r.severity }
+func (r *rule) Check(s *serifu.Script, report Reporter) { r.check(s, report) }
+
+// DefaultRules returns the built-in rules
+func DefaultRules() []Rule {
+	return []Rule{
+		DuplicatePanelID,
+		PanelPageMismatch,
+		EmptyTextLine,
+		EmptyPage,
+		SingleUseSpeaker,
+	}
+}
+
+// DuplicatePanelID reports panel IDs used more than once on a page
+var DuplicatePanelID Rule = &rule{
+	name:     "duplicate-panel-id",
+	doc:      "panel IDs must be unique on a page",
+	severity: serifu.SeverityError,
+	check: func(s *serifu.Script, report Reporter) {
+		for _, p := range s.Pages {
+			seen := make(map[string]bool)
+			for _, pn := range p.Panels {
+				if seen[pn.ID] {
+					report(pn.Start, fmt.Sprintf("duplicate panel ID %q on page %q", pn.ID, p.Title))
+				}
+				seen[pn.ID] = true
+			}
+		}
+	},
+}
+
+// PanelPageMismatch reports panel IDs whose page prefix does not match the
+// number of the page, like "- 2.3" under "# PAGE 3"
+var PanelPageMismatch Rule = &rule{
+	name:     "panel-page-mismatch",
+	doc:      "the page part of a panel ID must match the page number",
+	severity: serifu.SeverityWarning,
+	check: func(s *serifu.Script, report Reporter) {
+		for _, p := range s.Pages {
+			numbers := pageNumbers(p.Title)
+			if len(numbers) == 0 {
+				continue
+			}
+			for _, pn := range p.Panels {
+				prefix, _, found := cut(pn.ID, ".")
+				n, err := strconv.Atoi(prefix)
+				if !found || err != nil || numbers[n] {
+					continue
+				}
+				report(pn.Start, fmt.Sprintf("panel %q does not belong to page %q", pn.ID, p.Title))
+			}
+		}
+	},
+}
+
+// EmptyTextLine reports text lines without content
+var EmptyTextLine Rule = &rule{
+	name:     "empty-text-line",
+	doc:      "text lines must have content",
+	severity: serifu.SeverityWarning,
+	check: func(s *serifu.Script, report Reporter) {
+		eachTextLine(s, func(t *serifu.TextLine) {
+			if strings.TrimSpace(t.Content) == "" {
+				report(t.Start, fmt.Sprintf("text line of %q has no content", t.Source))
+			}
+		})
+	},
+}
+
+// EmptyPage reports pages without panels
+var EmptyPage Rule = &rule{
+	name:     "empty-page",
+	doc:      "pages must have at least one panel",
+	severity: serifu.SeverityWarning,
+	check: func(s *serifu.Script, report Reporter) {
+		for _, p := range s.Pages {
+			if len(p.Panels) == 0 {
+				report(p.Start, fmt.Sprintf("page %q has no panels", p.Title))
+			}
+		}
+	},
+}
+
+// SingleUseSpeaker reports speakers appearing only once in the script,
+// which are often misspelled names
+var SingleUseSpeaker Rule = &rule{
+	name:     "single-use-speaker",
+	doc:      "speakers appearing only once are probably typos",
+	severity: serifu.SeverityWarning,
+	check: func(s *serifu.Script, report Reporter) {
+		count := make(map[string]int)
+		eachTextLine(s, func(t *serifu.TextLine) {
+			count[t.Source]++
+		})
+		eachTextLine(s, func(t *serifu.TextLine) {
+			if t.Source != "" && count[t.Source] == 1 {
+				report(t.Start, fmt.Sprintf("speaker %q appears only once", t.Source))
+			}
+		})
+	},
+}
+
+func eachTextLine(s *serifu.Script, fn func(t *serifu.TextLine)) {
+	for _, p := range s.Pages {
+		for _, pn := range p.Panels {
+			for _, item := range pn.Items {
+				if t, ok := item.(*serifu.TextLine); ok {
+					fn(t)
+				}
+			}
+		}
+	}
+}
+
+// pageNumbers returns the page numbers in a page title. Ranges like "4-5"
+// used for spreads include every page between.
+func pageNumbers(title string) map[int]bool {
+	numbers := make(map[int]bool)
+	fields := strings.FieldsFunc(title, func(r rune) bool {
+		return !unicode.IsDigit(r) && r != '-'
+	})
+	for _, f := range fields {
+		from, to, isRange := cut(f, "-")
+		a, err := strconv.Atoi(from)
+		if err != nil {
+			continue
+		}
+		b := a
+		if isRange {
+			if n, err := strconv.Atoi(to); err == nil && n >= a && n-a < 100 {
+				b = n
+			}
+		}
+		for i := a; i <= b; i++ {
+			numbers[i] = true
+		}
+	}
+	return numbers
+}
+
+// cut is strings.Cut which needs go 1.18
+func cut(s, sep string) (before, after string, found bool) {
+	if i := strings.Index(s, sep); i >= 0 {
+		return s[:i], s[i+len(sep):], true
+	}
+	return s, "", false
+}
@@ -0,0 +1,259 @@
+package serifu
+
+import (
+	"html"
+	"io"
+	"strings"
+)
+
+// MarkdownOptions controls the output of RenderMarkdown
+type MarkdownOptions struct {
+	// Tables writes the panels of every page as a single table with panel,
+	// speaker and text columns instead of a heading per panel
+	Tables bool
+}
+
+// RenderMarkdown writes s to w as a Markdown document. Pages are level two
+// headings and panels level three headings or table rows. Ruby is written as
+// inline HTML which most Markdown renderers keep.
+func RenderMarkdown(w io.Writer, s *Script, opts MarkdownOptions) error {
+	r := &markdownRenderer{w: w, opts: opts}
+	if s.Meta != nil {
+		r.meta(s.Meta)
+	}
+	for _, n := range s.Notes {
+		r.block(r.note(n))
+	}
+	for _, p := range s.Pages {
+		r.page(p)
+	}
+	return r.err
+}
+
+// markdownRenderer writes Markdown remembering the first write error
+type markdownRenderer struct {
+	w       io.Writer
+	opts    MarkdownOptions
+	started bool // a block was written
+	err     error
+}
+
+// block writes a block separated from the previous one by a blank line
+func (r *markdownRenderer) block(s string) {
+	if r.err != nil {
+		return
+	}
+	if r.started {
+		s = "\n" + s
+	}
+	r.started = true
+	_, r.err = io.WriteString(r.w, s+"\n")
+}
+
+func (r *markdownRenderer) meta(m *Meta) {
+	if title := strings.TrimSpace(m.Series + " " + m.Chapter); title != "" {
+		r.block("# " + markdownEscape(title))
+	}
+	var b strings.Builder
+	for i, f := range m.Fields() {
+		if i > 0 {
+			b.WriteByte('\n')
+		}
+		b.WriteString("- **" + markdownEscape(f[0]) + ":** " + markdownEscape(f[1]))
+	}
+	r.block(b.String())
+}
+
+func (r *markdownRenderer) page(p *Page) {
+	heading := "## " + markdownEscape(p.Title)
+	if p.IsSpread {
+		heading += " _(spread)_"
+	}
+	r.block(heading)
+	for _, n := range p.Notes {
+		r.block(r.note(n))
+	}
+	if r.opts.Tables {
+		r.table(p)
+		return
+	}
+	for _, pn := range p.Panels {
+		r.block("### " + markdownEscape(pn.ID))
+		for _, item := range pn.Items {
+			switch i := item.(type) {
+			case *TextLine:
+				r.block(r.textLine(i))
+			case *SoundEffect:
+				r.block(r.soundEffect(i))
+			case *SideNote:
+				r.block(r.note(i))
+			}
+		}
+	}
+}
+
+// table writes the panels of p as a table, one row per item
+func (r *markdownRenderer) table(p *Page) {
+	if len(p.Panels) == 0 {
+		return
+	}
+	var b strings.Builder
+	b.WriteString("| Panel | Speaker | Text |\n| --- | --- | --- |")
+	for _, pn := range p.Panels {
+		id := markdownEscape(pn.ID)
+		if len(pn.Items) == 0 {
+			b.WriteString("\n| " + id + " | | |")
+		}
+		for _, item := range pn.Items {
+			var speaker, text string
+			switch i := item.(type) {
+			case *TextLine:
+				speaker = r.heading(i)
+				text = r.content(i)
+			case *SoundEffect:
+				speaker = "SFX"
+				text = r.soundEffectText(i)
+			case *SideNote:
+				speaker = "Note"
+				text = "_" + markdownEscape(i.Content) + "_"
+			}
+			text = strings.ReplaceAll(text, "\n", "<br>")
+			b.WriteString("\n| " + id + " | " + speaker + " | " + text + " |")
+			id = ""
+		}
+	}
+	r.block(b.String())
+}
+
+// heading returns the speaker, style and kind of t
+func (r *markdownRenderer) heading(t *TextLine) string {
+	var parts []string
+	if t.Source != "" {
+		parts = append(parts, "**"+markdownEscape(t.Source)+"**")
+	}
+	var notes []string
+	if t.Style != "" {
+		notes = append(notes, markdownEscape(t.Style))
+	}
+	if t.LineKind != DialogueLine {
+		notes = append(notes, string(t.LineKind))
+	}
+	if len(notes) > 0 {
+		parts = append(parts, "_("+strings.Join(notes, ", ")+")_")
+	}
+	heading := strings.Join(parts, " ")
+	if t.Connected {
+		heading = "↳ " + heading
+	}
+	return heading
+}
+
+// content returns the content of t with the inline markup converted
+func (r *markdownRenderer) content(t *TextLine) string {
+	if t.IsPreFormatted {
+		return markdownEscape(strings.TrimSuffix(t.Content, "\n"))
+	}
+	spans := t.Spans
+	if spans == nil {
+		spans = ParseInline(t.Content)
+	}
+	return markdownSpans(spans)
+}
+
+func (r *markdownRenderer) textLine(t *TextLine) string {
+	heading := r.heading(t)
+	if t.IsPreFormatted && heading == "" {
+		return markdownFence(t.Content)
+	}
+	if t.IsPreFormatted {
+		return heading + ":\n\n" + markdownFence(t.Content)
+	}
+	if heading == "" {
+		return r.content(t)
+	}
+	return heading + ": " + r.content(t)
+}
+
+func (r *markdownRenderer) soundEffectText(se *SoundEffect) string {
+	s := "**" + markdownEscape(se.Name) + "**"
+	if se.Original != "" {
+		s += " " + markdownEscape(se.Original)
+	}
+	if se.Transliteration != "" {
+		s += " _(" + markdownEscape(se.Transliteration) + ")_"
+	}
+	if se.Placement != "" {
+		s += " — " + markdownEscape(se.Placement)
+	}
+	return s
+}
+
+func (r *markdownRenderer) soundEffect(se *SoundEffect) string {
+	return "SFX: " + r.soundEffectText(se)
+}
+
+func (r *markdownRenderer) note(n *SideNote) string {
+	return "> **Note:** " + markdownEscape(n.Content)
+}
+
+// markdownSpans returns spans as Markdown emphasis
+func markdownSpans(spans []*Span) string {
+	var b strings.Builder
+	for _, s := range spans {
+		switch s.Kind {
+		case ItalicSpan:
+			b.WriteString("*" + markdownSpans(s.Children) + "*")
+		case BoldSpan:
+			b.WriteString("**" + markdownSpans(s.Children) + "**")
+		case BoldItalicSpan:
+			b.WriteString("***" + markdownSpans(s.Children) + "***")
+		case RubySpan:
+			b.WriteString("<ruby>" + html.EscapeString(s.Text) + "<rp>(</rp><rt>" +
+				html.EscapeString(s.Ruby) + "</rt><rp>)</rp></ruby>")
+		default:
+			b.WriteString(markdownEscape(s.Text))
+		}
+	}
+	return b.String()
+}
+
+// markdownEscape escapes the characters Markdown could read as markup, and
+// the list markers and ordered list numbers starting a line
+func markdownEscape(s string) string {
+	var b strings.Builder
+	listNumber := -1 // position of the . or ) after a number starting a line
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		lineStart := i == 0 || s[i-1] == '\n'
+		if lineStart {
+			j := i
+			for j < len(s) && s[j] >= '0' && s[j] <= '9' {
+				j++
+			}
+			if j > i && j < len(s) && (s[j] == '.' || s[j] == ')') &&
+				(j+1 == len(s) || s[j+1] == ' ' || s[j+1] == '\t' || s[j+1] == '\n') {
+				listNumber = j
+			}
+		}
+		switch {
+		case strings.IndexByte("\\`*_[]<>|#~&", c) > -1:
+			b.WriteByte('\\')
+		case lineStart && (c == '-' || c == '+' || c == '='):
+			b.WriteByte('\\')
+		case i == listNumber:
+			b.WriteByte('\\')
+		}
+		b.WriteByte(c)
+	}
+	return b.String()
+}
+
+// markdownFence returns s as a fenced code block using a fence longer than
+// any backtick run in s
+func markdownFence(s string) string {
+	fence := "```"
+	for strings.Contains(s, fence) {
+		fence += "`"
+	}
+	return fence + "\n" + strings.TrimSuffix(s, "\n") + "\n" + fence
+}
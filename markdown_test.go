@@ -0,0 +1,103 @@
+package serifu
+
+import (
+	"strings"
+	"testing"
+)
+
+const markdownInput = `---
+series: Moriking
+chapter: 31
+---
+! French edition
+## PAGE 1 & 2
+! mirrored
+- 1.1
+Shota/Sharp: A _death match?!?_ <b> {死闘|shitou} #1 *star*
+* gasp (はあ | haa) [top right]
+(caption): Later
+& Aki (whisper): psst
+Sign:/=
+Menu: <1 Yen>
+=/
+- 1.2
+`
+
+func TestRenderMarkdown(t *testing.T) {
+	s, err := Parse(strings.NewReader(markdownInput))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	tests := []struct {
+		name string
+		opts MarkdownOptions
+		want string
+	}{
+		{
+			"headings",
+			MarkdownOptions{},
+			"# Moriking 31\n\n" +
+				"- **series:** Moriking\n- **chapter:** 31\n\n" +
+				"> **Note:** French edition\n\n" +
+				"## PAGE 1 \\& 2 _(spread)_\n\n" +
+				"> **Note:** mirrored\n\n" +
+				"### 1.1\n\n" +
+				"**Shota** _(Sharp)_: A *death match?!?* \\<b\\> <ruby>死闘<rp>(</rp><rt>shitou</rt><rp>)</rp></ruby> \\#1 \\*star\\*\n\n" +
+				"SFX: **gasp** はあ _(haa)_ — top right\n\n" +
+				"_(caption)_: Later\n\n" +
+				"↳ **Aki** _(whisper)_: psst\n\n" +
+				"**Sign**:\n\n```\nMenu: <1 Yen>\n```\n\n" +
+				"### 1.2\n",
+		},
+		{
+			"tables",
+			MarkdownOptions{Tables: true},
+			"# Moriking 31\n\n" +
+				"- **series:** Moriking\n- **chapter:** 31\n\n" +
+				"> **Note:** French edition\n\n" +
+				"## PAGE 1 \\& 2 _(spread)_\n\n" +
+				"> **Note:** mirrored\n\n" +
+				"| Panel | Speaker | Text |\n| --- | --- | --- |\n" +
+				"| 1.1 | **Shota** _(Sharp)_ | A *death match?!?* \\<b\\> <ruby>死闘<rp>(</rp><rt>shitou</rt><rp>)</rp></ruby> \\#1 \\*star\\* |\n" +
+				"|  | SFX | **gasp** はあ _(haa)_ — top right |\n" +
+				"|  | _(caption)_ | Later |\n" +
+				"|  | ↳ **Aki** _(whisper)_ | psst |\n" +
+				"|  | **Sign** | Menu: \\<1 Yen\\> |\n" +
+				"| 1.2 | | |\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var b strings.Builder
+			if err := RenderMarkdown(&b, s, tt.opts); err != nil {
+				t.Fatalf("RenderMarkdown() error = %v", err)
+			}
+			if got := b.String(); got != tt.want {
+				t.Errorf("RenderMarkdown() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMarkdownEscape(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"plain text", "plain text"},
+		{"- not a list", "\\- not a list"},
+		{"a_b*c`d", "a\\_b\\*c\\`d"},
+		{"[link](x) | <tag>", "\\[link\\](x) \\| \\<tag\\>"},
+		{"1. Later", "1\\. Later"},
+		{"12) Later", "12\\) Later"},
+		{"in 1. place", "in 1. place"},
+		{"1.5 times", "1.5 times"},
+		{"first\n2. second\n+ third", "first\n2\\. second\n\\+ third"},
+		{"Tom &amp; Jerry", "Tom \\&amp; Jerry"},
+	}
+	for _, tt := range tests {
+		if got := markdownEscape(tt.in); got != tt.want {
+			t.Errorf("markdownEscape(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package serifu
+
+import (
+	"sort"
+	"strings"
+)
+
+const frontMatterDelimiter = "---"
+
+// Meta is the script metadata read from the front matter block before the
+// first page:
+//
+//	---
+//	series: Moriking
+//	chapter: 31
+//	translator: Jane Doe
+//	---
+//
+// Keys are case insensitive, unknown keys are kept in Extra.
+type Meta struct {
+	Range      `json:"-"`
+	Series     string            `json:"series,omitempty"`
+	Chapter    string            `json:"chapter,omitempty"`
+	Volume     string            `json:"volume,omitempty"`
+	Language   string            `json:"language,omitempty"`
+	Translator string            `json:"translator,omitempty"`
+	Letterer   string            `json:"letterer,omitempty"`
+	Editor     string            `json:"editor,omitempty"`
+	Revision   string            `json:"revision,omitempty"`
+	Extra      map[string]string `json:"extra,omitempty"`
+}
+
+// metaFields are the known front matter keys in printing order. The first
+// name of every field is the one printed.
+var metaFields = []struct {
+	names []string
+	field func(m *Meta) *string
+}{
+	{[]string{"series", "title", "series title"}, func(m *Meta) *string { return &m.Series }},
+	{[]string{"chapter"}, func(m *Meta) *string { return &m.Chapter }},
+	{[]string{"volume"}, func(m *Meta) *string { return &m.Volume }},
+	{[]string{"language", "source language"}, func(m *Meta) *string { return &m.Language }},
+	{[]string{"translator"}, func(m *Meta) *string { return &m.Translator }},
+	{[]string{"letterer"}, func(m *Meta) *string { return &m.Letterer }},
+	{[]string{"editor"}, func(m *Meta) *string { return &m.Editor }},
+	{[]string{"revision", "date", "revision date"}, func(m *Meta) *string { return &m.Revision }},
+}
+
+// Set sets the value of a front matter key
+func (m *Meta) Set(key, value string) {
+	name := strings.ToLower(strings.TrimSpace(key))
+	for _, f := range metaFields {
+		for _, n := range f.names {
+			if n == name {
+				*f.field(m) = value
+				return
+			}
+		}
+	}
+	if m.Extra == nil {
+		m.Extra = make(map[string]string)
+	}
+	m.Extra[strings.TrimSpace(key)] = value
+}
+
+// Fields returns the keys and values of the metadata in printing order
+func (m *Meta) Fields() [][2]string {
+	var fields [][2]string
+	for _, f := range metaFields {
+		if v := *f.field(m); v != "" {
+			fields = append(fields, [2]string{f.names[0], v})
+		}
+	}
+	keys := make([]string, 0, len(m.Extra))
+	for k := range m.Extra {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		fields = append(fields, [2]string{k, m.Extra[k]})
+	}
+	return fields
+}
+
+// frontMatter returns the front matter block of m
+func (d *Dialect) frontMatter(m *Meta) string {
+	var b strings.Builder
+	b.WriteString(d.FrontMatterDelimiter)
+	b.WriteByte('\n')
+	for _, f := range m.Fields() {
+		b.WriteString(f[0] + d.TextLineSeparator + " " + f[1] + "\n")
+	}
+	b.WriteString(d.FrontMatterDelimiter)
+	return b.String()
+}
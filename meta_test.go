@@ -0,0 +1,118 @@
+package serifu
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const metaInput = `
+---
+Series: Moriking
+chapter: 31
+Volume: 4
+source language: ja
+Translator: Jane Doe
+Letterer: John Roe
+Editor: Ed
+Date: 2021-05-01
+Cover Artist: Someone
+---
+
+# PAGE 1
+- 1.1
+Shota: Hi
+`
+
+func TestParse_frontMatter(t *testing.T) {
+	got, err := Parse(strings.NewReader(metaInput))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	want := &Meta{
+		Range:      Range{Position{1, 2, 1}, Position{157, 12, 4}},
+		Series:     "Moriking",
+		Chapter:    "31",
+		Volume:     "4",
+		Language:   "ja",
+		Translator: "Jane Doe",
+		Letterer:   "John Roe",
+		Editor:     "Ed",
+		Revision:   "2021-05-01",
+		Extra:      map[string]string{"Cover Artist": "Someone"},
+	}
+	if !reflect.DeepEqual(got.Meta, want) {
+		t.Errorf("Meta = %#v, want %#v", got.Meta, want)
+	}
+
+	var b strings.Builder
+	if err := Format(&b, got, FormatOptions{}); err != nil {
+		t.Fatalf("Format() error = %v", err)
+	}
+	wantText := `---
+series: Moriking
+chapter: 31
+volume: 4
+language: ja
+translator: Jane Doe
+letterer: John Roe
+editor: Ed
+revision: 2021-05-01
+Cover Artist: Someone
+---
+
+# PAGE 1
+- 1.1
+Shota: Hi
+`
+	if b.String() != wantText {
+		t.Errorf("Format() = %q, want %q", b.String(), wantText)
+	}
+
+	doc, err := ParseDocument(strings.NewReader(metaInput), ParserOptions{})
+	if err != nil {
+		t.Fatalf("ParseDocument() error = %v", err)
+	}
+	if doc.String() != metaInput {
+		t.Errorf("Document.String() = %q, want %q", doc.String(), metaInput)
+	}
+}
+
+func TestParse_frontMatterErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		wantKind ErrorKind
+		wantLine int
+	}{
+		{"invalid line", "---\nseries: A\nnonsense\n---\n", InvalidMetadata, 3},
+		{"unterminated", "---\nseries: A\n# PAGE 1\n", UnterminatedBlock, 1},
+		{"after a page", "# PAGE 1\n---\n", PanelOutsidePage, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse(strings.NewReader(tt.input))
+			var pe *ParseError
+			if tt.wantLine == 0 {
+				if err != nil {
+					t.Errorf("Parse() error = %v, want nil", err)
+				}
+				return
+			}
+			if !errors.As(err, &pe) || pe.Kind != tt.wantKind || pe.Pos.Line != tt.wantLine {
+				t.Errorf("Parse() error = %v, want %s on line %d", err, tt.wantKind, tt.wantLine)
+			}
+		})
+	}
+}
+
+func TestParseWithOptions_recoverFrontMatter(t *testing.T) {
+	got, err := ParseWithOptions(strings.NewReader("---\nseries: A\n# PAGE 1\n- 1.1\n"), ParserOptions{Recover: true})
+	if err == nil {
+		t.Errorf("ParseWithOptions() error = nil, want error")
+	}
+	if got.Meta != nil || len(got.Pages) != 1 || len(got.Pages[0].Panels) != 1 {
+		t.Errorf("ParseWithOptions() = %v, want the page after the front matter", got)
+	}
+}
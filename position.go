@@ -0,0 +1,144 @@
+package serifu
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"unicode"
+)
+
+// Position is a location in the parsed input
+type Position struct {
+	Offset int // byte offset, starting at 0
+	Line   int // line number, starting at 1
+	Column int // column number in bytes, starting at 1
+}
+
+func (p Position) String() string {
+	return fmt.Sprintf("%d:%d", p.Line, p.Column)
+}
+
+// Range is the part of the input a node was parsed from. End points just
+// past the last non-blank byte of the node.
+type Range struct {
+	Start Position
+	End   Position
+}
+
+// Extent returns the range of the input covered by the node
+func (r Range) Extent() Range {
+	return r
+}
+
+// inputLine is a single line of the input
+type inputLine struct {
+	raw    string // the line including the line terminator
+	text   string // the line without the line terminator
+	number int    // number of the line
+	offset int    // byte offset of the line
+}
+
+// lineReader reads the input line by line keeping track of the offset of
+// every line
+type lineReader struct {
+	inputLine
+	scanner *bufio.Scanner
+	next    int         // byte offset of the next line
+	unread  []inputLine // lines to return again before reading more
+	keep    bool        // keep the raw lines until they are taken
+	kept    strings.Builder
+}
+
+func newLineReader(r io.Reader) *lineReader {
+	scanner := bufio.NewScanner(r)
+	scanner.Split(scanRawLines)
+	return &lineReader{scanner: scanner}
+}
+
+// Scan advances to the next line
+func (lr *lineReader) Scan() bool {
+	if len(lr.unread) > 0 {
+		lr.inputLine = lr.unread[0]
+		lr.unread = lr.unread[1:]
+	} else {
+		if !lr.scanner.Scan() {
+			return false
+		}
+		raw := lr.scanner.Text()
+		lr.inputLine = inputLine{
+			raw:    raw,
+			text:   strings.TrimSuffix(strings.TrimSuffix(raw, "\n"), "\r"),
+			number: lr.number + 1,
+			offset: lr.next,
+		}
+		lr.next += len(raw)
+	}
+	if lr.keep {
+		lr.kept.WriteString(lr.raw)
+	}
+	return true
+}
+
+// Unread pushes back lines already read so Scan returns them again. The
+// lines must be the last ones read, in order.
+func (lr *lineReader) Unread(lines []inputLine) {
+	lr.unread = append(append([]inputLine{}, lines...), lr.unread...)
+	if lr.keep {
+		n := 0
+		for _, l := range lines {
+			n += len(l.raw)
+		}
+		kept := lr.kept.String()
+		lr.kept.Reset()
+		lr.kept.WriteString(kept[:len(kept)-n])
+	}
+}
+
+// take returns the raw lines read since the last call when keep is set
+func (lr *lineReader) take() string {
+	s := lr.kept.String()
+	lr.kept.Reset()
+	return s
+}
+
+// Err returns the first non-EOF error of the underlying scanner
+func (lr *lineReader) Err() error {
+	return lr.scanner.Err()
+}
+
+// pos returns the position of byte i of the current line
+func (lr *lineReader) pos(i int) Position {
+	return Position{
+		Offset: lr.offset + i,
+		Line:   lr.number,
+		Column: i + 1,
+	}
+}
+
+// trimmedRange returns the range of the current line without the
+// surrounding white space
+func (lr *lineReader) trimmedRange() Range {
+	start := len(lr.text) - len(strings.TrimLeftFunc(lr.text, unicode.IsSpace))
+	end := len(strings.TrimRightFunc(lr.text, unicode.IsSpace))
+	if end < start {
+		end = start
+	}
+	return Range{lr.pos(start), lr.pos(end)}
+}
+
+// scanRawLines is a bufio.SplitFunc like bufio.ScanLines which keeps the
+// line terminator so offsets can be tracked
+func scanRawLines(data []byte, atEOF bool) (advance int, token []byte, err error) {
+	if atEOF && len(data) == 0 {
+		return 0, nil, nil
+	}
+	if i := bytes.IndexByte(data, '\n'); i >= 0 {
+		return i + 1, data[:i+1], nil
+	}
+	if atEOF {
+		return len(data), data, nil
+	}
+	return 0, nil, nil
+}
@@ -2,10 +2,10 @@
 package serifu
 
 import (
-	"bufio"
 	"fmt"
 	"io"
 	"strings"
+	"unicode"
 )
 
 type parseState = int
@@ -26,6 +26,7 @@ const (
 	styleSeparator         = "/"
 	preFormattedBlockStart = "/="
 	preFormattedBlockEnd   = "=/"
+	connectedPrefix        = "&"
 )
 
 // ItemType represents the type of the item in the panel
@@ -33,243 +34,572 @@ type ItemType string
 
 const (
 	// TextLineItemType is the type for a TextLine
-	TextLineItemType = "text"
+	TextLineItemType ItemType = "text"
 	// SideNoteItemType is the type for a SideNote
-	SideNoteItemType = "sideNote"
+	SideNoteItemType ItemType = "sideNote"
 	// SoundEffectItemType is the type for a SoundEffect
-	SoundEffectItemType = "soundEffect"
+	SoundEffectItemType ItemType = "soundEffect"
 )
 
 // TextLine is a text line item
 type TextLine struct {
+	Range          `json:"-"`
 	Type           ItemType `json:"type"`
 	Source         string   `json:"source"`
 	Style          string   `json:"style"`
 	IsPreFormatted bool     `json:"is_pre_formatted"`
-	Content        string   `json:"content"`
+	LineKind       LineKind `json:"kind,omitempty"`
+	// Connected marks a balloon joined to the balloon of the previous text
+	// line with a bridge. It is written with a leading `&`.
+	Connected bool   `json:"connected,omitempty"`
+	Content   string `json:"content"`
+	Attrs     Attrs  `json:"attrs,omitempty"`
+	// Spans is the rich text tree of Content. It is set by the parser
+	// when ParserOptions.InlineMarkup is enabled.
+	Spans []*Span `json:"spans,omitempty"`
 }
 
-// SideNote is a side note item used for comments
+// LineKind is the kind of lettering a text line is meant for. It is written
+// in parentheses after the source and style, like `Shota (thought):` or
+// `(caption):` for lines without a speaker.
+type LineKind string
+
+const (
+	// DialogueLine is spoken text in a balloon, the default kind
+	DialogueLine LineKind = ""
+	// ThoughtLine is text in a thought balloon
+	ThoughtLine LineKind = "thought"
+	// CaptionLine is text in a caption box
+	CaptionLine LineKind = "caption"
+	// NarrationLine is narration in a caption box
+	NarrationLine LineKind = "narration"
+	// WhisperLine is whispered speech
+	WhisperLine LineKind = "whisper"
+	// OffPanelLine is speech of a speaker outside of the panel
+	OffPanelLine LineKind = "off-panel"
+)
+
+var lineKinds = []LineKind{ThoughtLine, CaptionLine, NarrationLine, WhisperLine, OffPanelLine}
+
+func (k LineKind) String() string {
+	if k == DialogueLine {
+		return "dialogue"
+	}
+	return string(k)
+}
+
+// cutLineKind splits the kind in parentheses from the end of a text line
+// heading. Headings ending with other parentheses are returned unchanged.
+func cutLineKind(heading string) (string, LineKind) {
+	if !strings.HasSuffix(heading, ")") {
+		return heading, DialogueLine
+	}
+	open := strings.LastIndex(heading, "(")
+	if open < 0 {
+		return heading, DialogueLine
+	}
+	name := strings.ToLower(strings.TrimSpace(heading[open+1 : len(heading)-1]))
+	rest := strings.TrimSpace(heading[:open])
+	if name == DialogueLine.String() {
+		return rest, DialogueLine
+	}
+	for _, k := range lineKinds {
+		if name == string(k) {
+			return rest, k
+		}
+	}
+	return heading, DialogueLine
+}
+
+// SideNote is a side note item used for comments. Side notes are panel
+// items, or page and script notes when they come before the first panel of a
+// page or before the first page.
 type SideNote struct {
+	Range   `json:"-"`
 	Type    ItemType `json:"type"`
 	Content string   `json:"content"`
 }
 
 // SoundEffect contains sound effect definition
 type SoundEffect struct {
-	Type            ItemType `json:"type"`
-	Name            string   `json:"name"`
-	Transliteration string   `json:"transliteration"`
+	Range `json:"-"`
+	Type  ItemType `json:"type"`
+	// Name is the English rendering of the sound
+	Name string `json:"name"`
+	// Transliteration is the romanization, several readings are separated
+	// by commas
+	Transliteration string `json:"transliteration"`
+	// Original is the sound in the original script, like kana
+	Original string `json:"original,omitempty"`
+	// Placement is a note on where the sound is on the panel
+	Placement string `json:"placement,omitempty"`
+	Attrs     Attrs  `json:"attrs,omitempty"`
 }
 
+// Item is an element of a panel. It is implemented by *TextLine,
+// *SoundEffect and *SideNote only.
+type Item interface {
+	// Kind returns the type of the item
+	Kind() ItemType
+	// Extent returns the part of the input the item was parsed from
+	Extent() Range
+	// String returns the item as Serifu markup
+	String() string
+	item()
+}
+
+// Kind returns TextLineItemType
+func (*TextLine) Kind() ItemType { return TextLineItemType }
+
+// Kind returns SideNoteItemType
+func (*SideNote) Kind() ItemType { return SideNoteItemType }
+
+// Kind returns SoundEffectItemType
+func (*SoundEffect) Kind() ItemType { return SoundEffectItemType }
+
+func (*TextLine) item()    {}
+func (*SideNote) item()    {}
+func (*SoundEffect) item() {}
+
 // Items contains list of items per panel
-type Items = []interface{}
+type Items = []Item
 
 // Panel contains comics panel items
 type Panel struct {
+	Range `json:"-"`
 	ID    string `json:"id"`
+	Attrs Attrs  `json:"attrs,omitempty"`
 	Items Items  `json:"items"`
 }
 
 // Page is a comic page containing one or more panels
 type Page struct {
-	Title    string   `json:"title"`
-	IsSpread bool     `json:"is_spread"`
-	Panels   []*Panel `json:"panels"`
+	Range    `json:"-"`
+	Title    string `json:"title"`
+	IsSpread bool   `json:"is_spread"`
+	Attrs    Attrs  `json:"attrs,omitempty"`
+	// Notes are the side notes between the page definition and its first
+	// panel. They apply to the whole page.
+	Notes  []*SideNote `json:"notes,omitempty"`
+	Panels []*Panel    `json:"panels"`
 }
 
 // Script is the whole script
 type Script struct {
-	Pages []*Page `json:"pages"`
+	Meta *Meta `json:"meta,omitempty"`
+	// Notes are the side notes before the first page. They apply to the
+	// whole script.
+	Notes []*SideNote `json:"notes,omitempty"`
+	Pages []*Page     `json:"pages"`
+}
+
+// ParserOptions controls the behaviour of ParseWithOptions
+type ParserOptions struct {
+	// Recover makes the parser skip invalid lines instead of stopping at the
+	// first one. The partial script is returned together with an ErrorList
+	// containing every problem found.
+	Recover bool
+	// InlineMarkup makes the parser fill TextLine.Spans with the inline
+	// markup of every text line which is not pre-formatted
+	InlineMarkup bool
+	// Dialect sets the markers to recognize, nil uses DefaultDialect
+	Dialect *Dialect
 }
 
 // Parse parses the input stream and returns script or error
-func Parse(io io.Reader) (*Script, error) {
-	script := &Script{make([]*Page, 0)}
-	scanner := bufio.NewScanner(io)
-
-	state := inScriptState
-	var line string
-	var trimmedLine string
-	var page *Page
-	var panel *Panel
-	lineNumber := 0
-
-	for scanner.Scan() {
-		lineNumber++
-		line = scanner.Text()
-		trimmedLine = strings.TrimSpace(line)
-
-		if strings.HasPrefix(line, pagePrefix) {
-			state = inPageState
-			var title string
-			isSpread := false
-			if strings.HasPrefix(trimmedLine, pageSpreadPrefix) {
-				title = strings.TrimSpace(trimmedLine[2:])
-				isSpread = true
-			} else {
-				title = strings.TrimSpace(trimmedLine[1:])
-			}
-			page = &Page{
-				Title:    title,
-				IsSpread: isSpread,
-			}
-			script.Pages = append(script.Pages, page)
-			continue
+func Parse(r io.Reader) (*Script, error) {
+	return ParseWithOptions(r, ParserOptions{})
+}
+
+// ParseWithOptions parses the input stream using opts. Problems in the
+// input are reported as *ParseError or, when recovering, as ErrorList.
+func ParseWithOptions(r io.Reader, opts ParserOptions) (*Script, error) {
+	return newParser(r, opts).parse()
+}
+
+// parser holds the state of a single parse run
+type parser struct {
+	opts    ParserOptions
+	d       *Dialect
+	lr      *lineReader
+	script  *Script
+	state   parseState
+	page    *Page
+	panel   *Panel
+	errors  ErrorList
+	started bool        // a line other than blank lines was parsed
+	doc     *Document   // document to record the raw input into, if any
+	node    interface{} // node created by the last parsed line
+}
+
+func newParser(r io.Reader, opts ParserOptions) *parser {
+	return &parser{
+		opts:   opts,
+		d:      opts.Dialect.complete(),
+		lr:     newLineReader(r),
+		script: &Script{Pages: make([]*Page, 0)},
+		state:  inScriptState,
+	}
+}
+
+func (p *parser) parse() (*Script, error) {
+	for {
+		ok, err := p.scan()
+		if err != nil {
+			return nil, err
 		}
-		if strings.HasPrefix(line, panelPrefix) {
-			if state != inPageState && state != inPanelState {
-				return nil, fmt.Errorf("line %d: unexpected panel definition outside of page", lineNumber)
-			}
-			state = inPanelState
-			id := strings.TrimSpace(trimmedLine[1:])
-			panel = &Panel{
-				ID: id,
-			}
-			page.Panels = append(page.Panels, panel)
-			continue
+		if !ok {
+			break
 		}
-		if strings.HasPrefix(line, soundPrefix) {
-			if state != inPanelState {
-				return nil, fmt.Errorf("line %d: unexpected sound definition outside of panel", lineNumber)
-			}
-			name := strings.TrimSpace(trimmedLine[1:])
-			index := strings.Index(name, "(")
-			transliteration := ""
-			if index > -1 && strings.HasSuffix(name, ")") {
-				transliteration = name[index+1 : len(name)-2]
-				name = strings.TrimSpace(name[:index])
+	}
+	if p.doc != nil {
+		p.doc.finish(p.script)
+	}
+	if p.opts.Recover {
+		return p.script, p.errors.Err()
+	}
+	return p.script, nil
+}
+
+// scan parses the next line of the input. It returns false at the end of
+// the input.
+func (p *parser) scan() (bool, error) {
+	if !p.lr.Scan() {
+		return false, p.lr.Err()
+	}
+	p.node = nil
+	if err := p.parseLine(); err != nil {
+		if !p.opts.Recover {
+			return false, err
+		}
+		p.errors = append(p.errors, err)
+	}
+	if p.doc != nil {
+		p.doc.record(p.node, p.lr.take())
+	}
+	return true, nil
+}
+
+// errorf returns a ParseError for the current line
+func (p *parser) errorf(kind ErrorKind, format string, args ...interface{}) *ParseError {
+	return p.errorAt(p.lr.trimmedRange().Start, kind, format, args...)
+}
+
+// errorAt returns a ParseError at pos
+func (p *parser) errorAt(pos Position, kind ErrorKind, format string, args ...interface{}) *ParseError {
+	return &ParseError{
+		Pos:      pos,
+		Severity: SeverityError,
+		Kind:     kind,
+		Msg:      fmt.Sprintf(format, args...),
+	}
+}
+
+// extend grows the current page and panel to include end
+func (p *parser) extend(end Position) {
+	if p.panel != nil {
+		p.panel.End = end
+	}
+	if p.page != nil {
+		p.page.End = end
+	}
+}
+
+// resumeAtMarker pushes back the lines from the first page or panel
+// definition on so parsing continues there
+func (p *parser) resumeAtMarker(lines []inputLine) {
+	for i, l := range lines {
+		trimmed := strings.TrimSpace(l.text)
+		if p.isPageLine(trimmed) || strings.HasPrefix(trimmed, p.d.PanelPrefix) {
+			p.lr.Unread(lines[i:])
+			return
+		}
+	}
+}
+
+// continueLine appends the text of the following lines indented deeper
+// than indent to content. Lines starting with a marker end the
+// continuation. It returns the joined content and the end of
+// the last line appended.
+func (p *parser) continueLine(content string, indent int, end Position) (string, Position) {
+	lr := p.lr
+	for lr.Scan() {
+		rng := lr.trimmedRange()
+		if rng.Start.Offset == rng.End.Offset || rng.Start.Column-1 <= indent ||
+			p.isMarkerLine(strings.TrimSpace(lr.text)) {
+			lr.Unread([]inputLine{lr.inputLine})
+			break
+		}
+		if content != "" {
+			content += " "
+		}
+		content += strings.TrimSpace(lr.text)
+		end = rng.End
+	}
+	return content, end
+}
+
+// parseFrontMatter parses the metadata block starting at the current line
+func (p *parser) parseFrontMatter() *ParseError {
+	lr := p.lr
+	p.started = true
+	rng := lr.trimmedRange()
+	meta := &Meta{}
+	var errs ErrorList
+	var ingested []inputLine
+	for lr.Scan() {
+		ingested = append(ingested, lr.inputLine)
+		trimmed := strings.TrimSpace(lr.text)
+		if trimmed == p.d.FrontMatterDelimiter {
+			meta.Range = Range{rng.Start, lr.trimmedRange().End}
+			p.script.Meta = meta
+			p.node = meta
+			if len(errs) == 0 {
+				return nil
 			}
-			sound := &SoundEffect{
-				Type:            SoundEffectItemType,
-				Name:            name,
-				Transliteration: transliteration,
+			if !p.opts.Recover {
+				return errs[0]
 			}
-			panel.Items = append(panel.Items, sound)
+			p.errors = append(p.errors, errs[:len(errs)-1]...)
+			return errs[len(errs)-1]
+		}
+		if trimmed == "" {
 			continue
 		}
-		if strings.HasPrefix(line, sideNotePrefix) {
-			if state != inPanelState {
-				return nil, fmt.Errorf("line %d: unexpected side note definition outside of panel", lineNumber)
-			}
-			sideNote := strings.TrimSpace(trimmedLine[1:])
-			panel.Items = append(panel.Items, SideNote{
-				Type:    SideNoteItemType,
-				Content: sideNote,
-			})
+		index := strings.Index(trimmed, p.d.TextLineSeparator)
+		if index < 0 {
+			errs = append(errs, p.errorf(InvalidMetadata, "invalid metadata: `%s`", lr.text))
 			continue
 		}
-		index := strings.Index(line, textLineSeparator)
-		if index > -1 {
-			if state != inPanelState {
-				return nil, fmt.Errorf("line %d: unexpected text line definition outside of panel", lineNumber)
-			}
-			isPreFormatted := false
-			source := strings.TrimSpace(trimmedLine[:index])
-			content := strings.TrimSpace(trimmedLine[index+1:])
-			if strings.HasPrefix(content, preFormattedBlockStart) {
-				isPreFormatted = true
-				if strings.HasSuffix(content, preFormattedBlockEnd) {
-					content = content[2 : len(content)-3]
-					// single line block
-				} else {
-					// multi-line block
-					var b strings.Builder
-					b.WriteString(content[2:])
-					// ingest block
-					for scanner.Scan() {
-						lineNumber++
-						line = scanner.Text()
-						trimmedLine = strings.TrimSpace(line)
-						if strings.HasSuffix(trimmedLine, preFormattedBlockEnd) {
-							content = b.String()
-							break
-						}
-						b.WriteString(line)
-						b.WriteByte('\n')
+		meta.Set(trimmed[:index], strings.TrimSpace(trimmed[index+len(p.d.TextLineSeparator):]))
+	}
+	if p.opts.Recover {
+		p.resumeAtMarker(ingested)
+	}
+	return p.errorAt(rng.Start, UnterminatedBlock, "unterminated front matter")
+}
+
+// isMarkerLine reports if the trimmed line starts with a marker. Text line
+// separators do not count as they are common in prose.
+func (p *parser) isMarkerLine(trimmed string) bool {
+	d := p.d
+	for _, prefix := range []string{d.PanelPrefix, d.SoundPrefix, d.SideNotePrefix, d.ConnectedPrefix,
+		d.LineCommentPrefix, d.BlockCommentStart} {
+		if strings.HasPrefix(trimmed, prefix) {
+			return true
+		}
+	}
+	return p.isPageLine(trimmed)
+}
+
+// isPageLine reports if the trimmed line defines a page
+func (p *parser) isPageLine(trimmed string) bool {
+	return strings.HasPrefix(trimmed, p.d.PageSpreadPrefix) || strings.HasPrefix(trimmed, p.d.PagePrefix)
+}
+
+func (p *parser) parseLine() *ParseError {
+	lr := p.lr
+	d := p.d
+	line := lr.text
+	trimmedLine := strings.TrimSpace(line)
+	rng := lr.trimmedRange()
+
+	if strings.HasPrefix(trimmedLine, d.LineCommentPrefix) {
+		return nil
+	}
+	if strings.HasPrefix(trimmedLine, d.BlockCommentStart) {
+		return p.parseBlockComment(trimmedLine[len(d.BlockCommentStart):])
+	}
+	if trimmedLine == d.FrontMatterDelimiter && !p.started {
+		return p.parseFrontMatter()
+	}
+	if trimmedLine != "" {
+		p.started = true
+	}
+	if p.isPageLine(trimmedLine) {
+		p.state = inPageState
+		var title string
+		isSpread := false
+		if strings.HasPrefix(trimmedLine, d.PageSpreadPrefix) {
+			title = strings.TrimSpace(trimmedLine[len(d.PageSpreadPrefix):])
+			isSpread = true
+		} else {
+			title = strings.TrimSpace(trimmedLine[len(d.PagePrefix):])
+		}
+		title, attrs := cutAttrs(title)
+		p.page = &Page{
+			Range:    rng,
+			Title:    title,
+			IsSpread: isSpread,
+			Attrs:    attrs,
+		}
+		p.panel = nil
+		p.script.Pages = append(p.script.Pages, p.page)
+		p.node = p.page
+		return nil
+	}
+	if strings.HasPrefix(trimmedLine, d.PanelPrefix) {
+		if p.state != inPageState && p.state != inPanelState {
+			return p.errorf(PanelOutsidePage, "unexpected panel definition outside of page")
+		}
+		p.state = inPanelState
+		id, attrs := cutAttrs(trimmedLine[len(d.PanelPrefix):])
+		p.panel = &Panel{
+			Range: rng,
+			ID:    id,
+			Attrs: attrs,
+		}
+		p.page.Panels = append(p.page.Panels, p.panel)
+		p.node = p.panel
+		p.extend(rng.End)
+		return nil
+	}
+	if strings.HasPrefix(trimmedLine, d.SoundPrefix) {
+		if p.state != inPanelState {
+			return p.errorf(SoundOutsidePanel, "unexpected sound definition outside of panel")
+		}
+		text, attrs := cutAttrs(trimmedLine[len(d.SoundPrefix):])
+		sound := parseSoundEffect(text)
+		sound.Range = rng
+		sound.Attrs = attrs
+		p.panel.Items = append(p.panel.Items, sound)
+		p.node = sound
+		p.extend(rng.End)
+		return nil
+	}
+	if strings.HasPrefix(trimmedLine, d.SideNotePrefix) {
+		sideNote := strings.TrimSpace(trimmedLine[len(d.SideNotePrefix):])
+		note := &SideNote{
+			Range:   rng,
+			Type:    SideNoteItemType,
+			Content: sideNote,
+		}
+		switch p.state {
+		case inPanelState:
+			p.panel.Items = append(p.panel.Items, note)
+		case inPageState:
+			p.page.Notes = append(p.page.Notes, note)
+		default:
+			p.script.Notes = append(p.script.Notes, note)
+		}
+		p.node = note
+		p.extend(rng.End)
+		return nil
+	}
+	index := strings.Index(trimmedLine, d.TextLineSeparator)
+	if index > -1 {
+		if p.state != inPanelState {
+			return p.errorf(TextLineOutsidePanel, "unexpected text line definition outside of panel")
+		}
+		isPreFormatted := false
+		connected := false
+		source := strings.TrimSpace(trimmedLine[:index])
+		if strings.HasPrefix(source, d.ConnectedPrefix) {
+			connected = true
+			source = strings.TrimSpace(source[len(d.ConnectedPrefix):])
+		}
+		content, attrs := cutAttrs(trimmedLine[index+len(d.TextLineSeparator):])
+		if strings.HasPrefix(content, d.PreFormattedBlockStart) {
+			isPreFormatted = true
+			if len(content) >= len(d.PreFormattedBlockStart)+len(d.PreFormattedBlockEnd) &&
+				strings.HasSuffix(content, d.PreFormattedBlockEnd) {
+				// single line block
+				content = content[len(d.PreFormattedBlockStart) : len(content)-len(d.PreFormattedBlockEnd)]
+			} else {
+				// multi-line block, text after the start marker is the first line
+				var b strings.Builder
+				if first := content[len(d.PreFormattedBlockStart):]; first != "" {
+					b.WriteString(first)
+					b.WriteByte('\n')
+				}
+				// ingest block
+				var ingested []inputLine
+				terminated := false
+				for lr.Scan() {
+					ingested = append(ingested, lr.inputLine)
+					line = strings.TrimRightFunc(lr.text, unicode.IsSpace)
+					if strings.HasSuffix(line, d.PreFormattedBlockEnd) {
+						// text before the end marker is the last line
+						b.WriteString(line[:len(line)-len(d.PreFormattedBlockEnd)])
+						rng.End = lr.trimmedRange().End
+						terminated = true
+						break
 					}
+					b.WriteString(lr.text)
+					b.WriteByte('\n')
 				}
+				if !terminated {
+					if p.opts.Recover {
+						p.resumeAtMarker(ingested)
+					}
+					return p.errorAt(rng.Start, UnterminatedBlock, "unterminated pre-formatted block")
+				}
+				content = b.String()
 			}
-			style := ""
-			styleIndex := strings.Index(source, styleSeparator)
-			if styleIndex > -1 {
-				style = source[styleIndex+1:]
-				source = source[:styleIndex]
-			}
-			textLine := TextLine{
-				Type:           TextLineItemType,
-				Source:         source,
-				Style:          style,
-				Content:        content,
-				IsPreFormatted: isPreFormatted,
+		} else {
+			content, rng.End = p.continueLine(content, rng.Start.Column-1, rng.End)
+			if attrs == nil {
+				content, attrs = cutAttrs(content)
 			}
-			panel.Items = append(panel.Items, textLine)
-			continue
 		}
-		if trimmedLine != "" {
-			return nil, fmt.Errorf("line %d: unexpected markup: `%s`", lineNumber, line)
+		source, kind := cutLineKind(source)
+		style := ""
+		styleIndex := strings.Index(source, d.StyleSeparator)
+		if styleIndex > -1 {
+			style = source[styleIndex+len(d.StyleSeparator):]
+			source = source[:styleIndex]
 		}
-
+		textLine := &TextLine{
+			Range:          rng,
+			Type:           TextLineItemType,
+			Source:         source,
+			Style:          style,
+			LineKind:       kind,
+			Connected:      connected,
+			Content:        content,
+			Attrs:          attrs,
+			IsPreFormatted: isPreFormatted,
+		}
+		if p.opts.InlineMarkup && !isPreFormatted {
+			textLine.Spans = ParseInline(content)
+		}
+		p.panel.Items = append(p.panel.Items, textLine)
+		p.node = textLine
+		p.extend(rng.End)
+		return nil
 	}
-
-	return script, nil
+	if trimmedLine != "" {
+		return p.errorf(UnexpectedMarkup, "unexpected markup: `%s`", line)
+	}
+	return nil
 }
 
 func (s Script) String() string {
 	var b strings.Builder
-	for _, p := range s.Pages {
-		b.WriteString(p.String())
-		b.WriteByte('\n')
-	}
+	Format(&b, &s, FormatOptions{})
 	return b.String()
 }
 
 func (p Page) String() string {
 	var b strings.Builder
-	if p.IsSpread {
-		b.WriteString(fmt.Sprintf("%s %s", pageSpreadPrefix, p.Title))
-	} else {
-		b.WriteString(fmt.Sprintf("%s %s", pagePrefix, p.Title))
-	}
-	b.WriteByte('\n')
-	for _, pn := range p.Panels {
-		b.WriteString(pn.String())
-		b.WriteByte('\n')
-	}
+	newFormatter(&b, FormatOptions{}).page(&p)
 	return b.String()
 }
 
 func (pn Panel) String() string {
 	var b strings.Builder
-	b.WriteString(fmt.Sprintf("    %s %s", panelPrefix, pn.ID))
-	b.WriteByte('\n')
-	for _, i := range pn.Items {
-		st := i.(fmt.Stringer)
-		b.WriteString(st.String())
-		b.WriteByte('\n')
-	}
+	newFormatter(&b, FormatOptions{}).panel(&pn)
 	return b.String()
 }
 
 func (t TextLine) String() string {
-	heading := t.Source
-	if t.Style != "" {
-		heading = fmt.Sprintf("%s%s%s", t.Source, styleSeparator, t.Source)
-	}
-	content := " " + t.Content
-	if t.IsPreFormatted {
-		content = fmt.Sprintf("%s%s%s", preFormattedBlockStart, t.Content, preFormattedBlockEnd)
-	}
-	return fmt.Sprintf("    %s%s%s", heading, textLineSeparator, content)
+	return DefaultDialect.textLine(&t, " ")
 }
 
 func (se SoundEffect) String() string {
-	if se.Transliteration != "" {
-		return fmt.Sprintf("    %s %s (%s)", soundPrefix, se.Name, se.Transliteration)
-	}
-	return fmt.Sprintf("    %s %s", soundPrefix, se.Name)
+	return DefaultDialect.soundEffect(&se)
 }
 
 func (sn SideNote) String() string {
-	return fmt.Sprintf("    %s %s", sideNotePrefix, sn.Content)
+	return DefaultDialect.item(&sn)
 }
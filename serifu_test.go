@@ -35,12 +35,34 @@ func TestParse(t *testing.T) {
 			true,
 		},
 		{
-			"side note out of panel",
+			"side notes out of panel",
 			args{
-				strings.NewReader(`! asd`),
+				strings.NewReader("! asd\n# PAGE 1\n! mirrored\n- 1.1\n! in panel"),
 			},
-			nil,
-			true,
+			&Script{
+				Notes: []*SideNote{
+					{Range{Position{0, 1, 1}, Position{5, 1, 6}}, SideNoteItemType, "asd"},
+				},
+				Pages: []*Page{
+					{
+						Range: Range{Position{6, 2, 1}, Position{42, 5, 11}},
+						Title: "PAGE 1",
+						Notes: []*SideNote{
+							{Range{Position{15, 3, 1}, Position{25, 3, 11}}, SideNoteItemType, "mirrored"},
+						},
+						Panels: []*Panel{
+							{
+								Range: Range{Position{26, 4, 1}, Position{42, 5, 11}},
+								ID:    "1.1",
+								Items: Items{
+									&SideNote{Range{Position{32, 5, 1}, Position{42, 5, 11}}, SideNoteItemType, "in panel"},
+								},
+							},
+						},
+					},
+				},
+			},
+			false,
 		},
 		{
 			"text entry out of panel",
@@ -107,9 +129,7 @@ func TestScript_String(t *testing.T) {
 					},
 				},
 			},
-			`# PAGE 1
-
-`,
+			"# PAGE 1\n",
 		},
 		{
 			"generates result for spread page",
@@ -121,9 +141,7 @@ func TestScript_String(t *testing.T) {
 					},
 				},
 			},
-			`## PAGE 1
-
-`,
+			"## PAGE 1\n",
 		},
 	}
 	for _, tt := range tests {
@@ -137,3 +155,188 @@ func TestScript_String(t *testing.T) {
 		})
 	}
 }
+
+func TestParse_positions(t *testing.T) {
+	input := "# PAGE 1\r\n- 1.1\r\nShota: Hi\r\n* gasp (haa)  \r\nSign:/=\r\nMenu\r\n=/\r\n! note"
+	got, err := Parse(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	page := got.Pages[0]
+	panel := page.Panels[0]
+	tests := []struct {
+		name string
+		got  Range
+		want Range
+	}{
+		{
+			"page",
+			page.Range,
+			Range{Position{0, 1, 1}, Position{69, 8, 7}},
+		},
+		{
+			"panel",
+			panel.Range,
+			Range{Position{10, 2, 1}, Position{69, 8, 7}},
+		},
+		{
+			"text line",
+			panel.Items[0].(*TextLine).Range,
+			Range{Position{17, 3, 1}, Position{26, 3, 10}},
+		},
+		{
+			"sound effect",
+			panel.Items[1].(*SoundEffect).Range,
+			Range{Position{28, 4, 1}, Position{40, 4, 13}},
+		},
+		{
+			"pre-formatted block",
+			panel.Items[2].(*TextLine).Range,
+			Range{Position{44, 5, 1}, Position{61, 7, 3}},
+		},
+		{
+			"side note",
+			panel.Items[3].(*SideNote).Range,
+			Range{Position{63, 8, 1}, Position{69, 8, 7}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.got != tt.want {
+				t.Errorf("Range = %v, want %v", tt.got, tt.want)
+			}
+		})
+	}
+}
+
+func TestItem_Kind(t *testing.T) {
+	got, err := Parse(strings.NewReader("# PAGE 1\n- 1.1\nShota: Hi\n* gasp\n! note\n"))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	var kinds []ItemType
+	for _, item := range got.Pages[0].Panels[0].Items {
+		kinds = append(kinds, item.Kind())
+	}
+	want := []ItemType{TextLineItemType, SoundEffectItemType, SideNoteItemType}
+	if !reflect.DeepEqual(kinds, want) {
+		t.Errorf("Kind() = %v, want %v", kinds, want)
+	}
+}
+
+func TestParse_lineKind(t *testing.T) {
+	tests := []struct {
+		line       string
+		wantSource string
+		wantStyle  string
+		wantKind   LineKind
+		wantString string
+	}{
+		{"Shota: Hi", "Shota", "", DialogueLine, "Shota: Hi"},
+		{"(caption): Meanwhile", "", "", CaptionLine, "(caption): Meanwhile"},
+		{"(Narration) : Years later", "", "", NarrationLine, "(narration): Years later"},
+		{"Shota/Small (thought): Hmm", "Shota", "Small", ThoughtLine, "Shota/Small (thought): Hmm"},
+		{"Aki (whisper): psst", "Aki", "", WhisperLine, "Aki (whisper): psst"},
+		{"Aki (off-panel): Over here", "Aki", "", OffPanelLine, "Aki (off-panel): Over here"},
+		{"Aki (dialogue): Hi", "Aki", "", DialogueLine, "Aki: Hi"},
+		{"Shota (older): Hi", "Shota (older)", "", DialogueLine, "Shota (older): Hi"},
+		{"Chapter Title: Kumo", "Chapter Title", "", DialogueLine, "Chapter Title: Kumo"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.line, func(t *testing.T) {
+			s, err := Parse(strings.NewReader("# PAGE 1\n- 1.1\n" + tt.line))
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			got := s.Pages[0].Panels[0].Items[0].(*TextLine)
+			if got.Source != tt.wantSource || got.Style != tt.wantStyle || got.LineKind != tt.wantKind {
+				t.Errorf("Parse() = %q, %q, %q, want %q, %q, %q",
+					got.Source, got.Style, got.LineKind, tt.wantSource, tt.wantStyle, tt.wantKind)
+			}
+			if got.String() != tt.wantString {
+				t.Errorf("TextLine.String() = %q, want %q", got.String(), tt.wantString)
+			}
+		})
+	}
+}
+
+const continuationInput = `# PAGE 1
+- 1.1
+Palawan/Serious: We came all this way
+    only to find
+    an empty room.
+& Palawan/Serious: And yet...
+Shota:
+  Wait!
+
+  not a continuation: after a blank line
+`
+
+func TestParse_continuationEndsAtMarkup(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"side note and sound", "A: hi\n    ! note\n    * boom\n", "- 1\nA: hi\n! note\n* boom\n"},
+		{"panel", "A: hi\n   - 2\n   B: x\n", "- 1\nA: hi\n- 2\nB: x\n"},
+		{"line comment", "A: hi\n    // comment\n", "- 1\nA: hi\n"},
+		{"block comment", "A: hi\n    /* comment */\n", "- 1\nA: hi\n"},
+		{"separator in prose", "Aki: We leave\n    at 10:30 sharp.\n", "- 1\nAki: We leave at 10:30 sharp.\n"},
+		{"connected line", "A: hi\n    & B: yo\n", "- 1\nA: hi\n& B: yo\n"},
+		{"page", "A: hi\n    # PAGE 2\n", "- 1\nA: hi\n\n# PAGE 2\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := Parse(strings.NewReader("# PAGE 1\n- 1\n" + tt.input))
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			var b strings.Builder
+			if err := Format(&b, s, FormatOptions{}); err != nil {
+				t.Fatalf("Format() error = %v", err)
+			}
+			if got := strings.TrimPrefix(b.String(), "# PAGE 1\n"); got != tt.want {
+				t.Errorf("Format() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParse_continuation(t *testing.T) {
+	s, err := Parse(strings.NewReader(continuationInput))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	items := s.Pages[0].Panels[0].Items
+	want := []struct {
+		content   string
+		connected bool
+		end       Position
+	}{
+		{"We came all this way only to find an empty room.", false, Position{88, 5, 19}},
+		{"And yet...", true, Position{118, 6, 30}},
+		{"Wait!", false, Position{133, 8, 8}},
+		{"after a blank line", false, Position{175, 10, 41}},
+	}
+	if len(items) != len(want) {
+		t.Fatalf("Parse() items = %v, want %d items", items, len(want))
+	}
+	for i, w := range want {
+		got := items[i].(*TextLine)
+		if got.Content != w.content || got.Connected != w.connected || got.End != w.end {
+			t.Errorf("item %d = %q, %v, %#v, want %q, %v, %#v", i, got.Content, got.Connected, got.End, w.content, w.connected, w.end)
+		}
+	}
+	if got, want := items[1].String(), "& Palawan/Serious: And yet..."; got != want {
+		t.Errorf("TextLine.String() = %q, want %q", got, want)
+	}
+
+	doc, err := ParseDocument(strings.NewReader(continuationInput), ParserOptions{})
+	if err != nil {
+		t.Fatalf("ParseDocument() error = %v", err)
+	}
+	if got := doc.String(); got != continuationInput {
+		t.Errorf("Document.String() = %q, want %q", got, continuationInput)
+	}
+}
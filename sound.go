@@ -0,0 +1,100 @@
+package serifu
+
+import "strings"
+
+const (
+	readingSeparator  = "|"
+	readingListSep    = ","
+	placementStart    = "["
+	placementEnd      = "]"
+	readingGroupStart = '('
+	readingGroupEnd   = ')'
+)
+
+// parseSoundEffect parses the text of a sound effect line after the
+// prefix:
+//
+//	name [(reading)] [[placement]]
+//
+// The reading is the last balanced parenthesized group and is either a
+// romanization or the original script and the romanization separated by a
+// bar: (ドン | don). Several romanizations are separated by commas.
+func parseSoundEffect(s string) *SoundEffect {
+	se := &SoundEffect{Type: SoundEffectItemType}
+	s = strings.TrimSpace(s)
+	if strings.HasSuffix(s, placementEnd) {
+		if i := strings.LastIndex(s, placementStart); i > -1 {
+			se.Placement = strings.TrimSpace(s[i+len(placementStart) : len(s)-len(placementEnd)])
+			s = strings.TrimSpace(s[:i])
+		}
+	}
+	if i := readingGroupIndex(s); i > -1 {
+		reading := strings.TrimSpace(s[i+1 : len(s)-1])
+		if j := strings.Index(reading, readingSeparator); j > -1 {
+			se.Original = strings.TrimSpace(reading[:j])
+			reading = strings.TrimSpace(reading[j+len(readingSeparator):])
+		}
+		se.Transliteration = reading
+		s = strings.TrimSpace(s[:i])
+	}
+	se.Name = s
+	return se
+}
+
+// readingGroupIndex returns the start of the balanced parenthesized group
+// ending s or -1
+func readingGroupIndex(s string) int {
+	if !strings.HasSuffix(s, string(readingGroupEnd)) {
+		return -1
+	}
+	depth := 0
+	for i := len(s) - 1; i >= 0; i-- {
+		switch s[i] {
+		case readingGroupEnd:
+			depth++
+		case readingGroupStart:
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// Readings returns the romanizations of the sound effect
+func (se *SoundEffect) Readings() []string {
+	if se.Transliteration == "" {
+		return nil
+	}
+	readings := strings.Split(se.Transliteration, readingListSep)
+	for i, r := range readings {
+		readings[i] = strings.TrimSpace(r)
+	}
+	return readings
+}
+
+// formatSoundEffectText returns the text of a sound effect line after the
+// prefix
+func formatSoundEffectText(se *SoundEffect) string {
+	var b strings.Builder
+	b.WriteString(se.Name)
+	if se.Original != "" || se.Transliteration != "" {
+		if b.Len() > 0 {
+			b.WriteByte(' ')
+		}
+		b.WriteByte(readingGroupStart)
+		if se.Original != "" {
+			b.WriteString(se.Original + " " + readingSeparator + " ")
+		}
+		b.WriteString(se.Transliteration)
+		b.WriteByte(readingGroupEnd)
+	}
+	if se.Placement != "" {
+		if b.Len() > 0 {
+			b.WriteByte(' ')
+		}
+		b.WriteString(placementStart + se.Placement + placementEnd)
+	}
+	return b.String()
+}
@@ -0,0 +1,45 @@
+package serifu
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseSoundEffect(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  *SoundEffect
+	}{
+		{"name only", " ha ha ha", &SoundEffect{Name: "ha ha ha"}},
+		{"reading", " gasp (haa)", &SoundEffect{Name: "gasp", Transliteration: "haa"}},
+		{"last character kept", "glare (jiii)", &SoundEffect{Name: "glare", Transliteration: "jiii"}},
+		{"original script", "thud (ドン | don)", &SoundEffect{Name: "thud", Original: "ドン", Transliteration: "don"}},
+		{"several readings", "thud (ドン|don, dosh)", &SoundEffect{Name: "thud", Original: "ドン", Transliteration: "don, dosh"}},
+		{"nested parentheses", "crash (gashan (loud))", &SoundEffect{Name: "crash", Transliteration: "gashan (loud)"}},
+		{"placement", "gasp (haa) [top right]", &SoundEffect{Name: "gasp", Transliteration: "haa", Placement: "top right"}},
+		{"placement without reading", "gasp [bottom]", &SoundEffect{Name: "gasp", Placement: "bottom"}},
+		{"parentheses inside name", "(sigh) whoosh", &SoundEffect{Name: "(sigh) whoosh"}},
+		{"unbalanced", "gasp (haa))", &SoundEffect{Name: "gasp (haa))"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.want.Type = SoundEffectItemType
+			got := parseSoundEffect(tt.input)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseSoundEffect() = %#v, want %#v", got, tt.want)
+			}
+			again := parseSoundEffect(formatSoundEffectText(got))
+			if !reflect.DeepEqual(again, got) {
+				t.Errorf("parseSoundEffect(formatSoundEffectText()) = %#v, want %#v", again, got)
+			}
+		})
+	}
+}
+
+func TestSoundEffect_Readings(t *testing.T) {
+	se := &SoundEffect{Transliteration: "don,  dosh "}
+	if got, want := se.Readings(), []string{"don", "dosh"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Readings() = %q, want %q", got, want)
+	}
+}